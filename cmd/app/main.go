@@ -8,6 +8,7 @@ import (
 	"chords_app/internal/services"
 	"chords_app/internal/web"
 	"chords_app/internal/web/handlers"
+	"context"
 	"log/slog"
 
 	"github.com/go-playground/validator/v10"
@@ -41,15 +42,41 @@ func main() {
 		return
 	}
 	opensrearchAdapter := opensearch.NewOpenSearchAdapter(opensearchClient, cfg.Opensearch.IndexName)
-	artistRepo := repositories.NewGormArtistRepository(db)
-	artistService := services.NewArtistService(artistRepo, opensrearchAdapter, db)
+	if err := opensrearchAdapter.EnsureIndex(); err != nil {
+		slog.Error("Failed to create opensearch index", slog.String("error", err.Error()))
+		return
+	}
+	outboxRepo := repositories.NewGormOutboxRepository()
+	artistRepo := repositories.NewGormArtistRepository()
+	artistService := services.NewArtistService(artistRepo, outboxRepo, db)
 	artistHandler := handlers.NewArtistHandlers(artistService, validate)
 
-	songRepo := repositories.NewGormSongRepository(db)
-	songService := services.NewSongService(songRepo, artistRepo)
+	songRepo := repositories.NewGormSongRepository()
+	songService := services.NewSongService(songRepo, artistRepo, outboxRepo, db)
 	songHandler := handlers.NewSongHandlers(songService, &cfg.Roles, validate)
+	if count, err := songService.BackfillSongKeys(); err != nil {
+		slog.Error("Failed to backfill song keys", slog.String("error", err.Error()))
+		return
+	} else if count > 0 {
+		slog.Info("Backfilled song keys", slog.Int("count", count))
+	}
+
+	searchService := services.NewSearchService(opensrearchAdapter, songRepo, artistRepo, db)
+	searchHandler := handlers.NewSearchHandlers(searchService)
+
+	chordHandler := handlers.NewChordHandlers(services.NewChordService())
+
+	exportService := services.NewExportService(songRepo, artistRepo, db)
+	exportHandler := handlers.NewExportHandlers(exportService)
+
+	importService := services.NewImportService(repositories.NewGormImportJobRepository(), songRepo, artistRepo, userRepo, outboxRepo, db)
+	importHandler := handlers.NewImportHandlers(importService)
+	go services.RunImportJobs(context.Background(), importService)
+
+	indexDispatcher := services.NewIndexDispatcher(outboxRepo, songRepo, artistRepo, opensrearchAdapter, db)
+	go indexDispatcher.Run(context.Background())
 
-	router := web.SetupRouter(userHandler, artistHandler, songHandler, userService, &cfg.Roles)
+	router := web.SetupRouter(userHandler, artistHandler, songHandler, searchHandler, chordHandler, exportHandler, importHandler, userService, &cfg.Roles)
 
 	slog.Info("Starting HTTP server", "host", cfg.Server.Host, "port", cfg.Server.Port)
 	if err := router.Run(cfg.Server.Host + ":" + cfg.Server.Port); err != nil {
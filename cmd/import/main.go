@@ -0,0 +1,90 @@
+package main
+
+import (
+	"chords_app/internal/config"
+	"chords_app/internal/database"
+	"chords_app/internal/repositories"
+	"chords_app/internal/services"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	archivePath := flag.String("archive", "", "zip, tar or tar.gz archive of ChordPro files to import")
+	uploadedBy := flag.Uint("user", 0, "ID of the user the imported songs are uploaded by")
+	resume := flag.Uint("resume", 0, "ID of an interrupted import job to continue instead of starting a new one")
+	flag.Parse()
+
+	if (*archivePath == "") == (*resume == 0) {
+		fmt.Fprintln(os.Stderr, "either -archive or -resume is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.SetupConfig()
+	if err != nil {
+		slog.Error("error in reading config:", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	db, err := database.SetupDatabase(&cfg.DB)
+	if err != nil {
+		slog.Error("error in setup database:", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	database.AutoMigrate(db)
+
+	importService := services.NewImportService(
+		repositories.NewGormImportJobRepository(),
+		repositories.NewGormSongRepository(),
+		repositories.NewGormArtistRepository(),
+		repositories.NewGormUserRepository(db),
+		repositories.NewGormOutboxRepository(),
+		db,
+	)
+
+	jobId := *resume
+	if jobId == 0 {
+		data, err := os.ReadFile(*archivePath)
+		if err != nil {
+			slog.Error("failed to read archive", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		job, err := importService.CreateJob(filepath.Base(*archivePath), data, *uploadedBy)
+		if err != nil {
+			slog.Error("failed to create import job", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		jobId = job.ID
+		slog.Info("Import job created", slog.Uint64("job", uint64(jobId)))
+	}
+
+	if err := importService.RunJob(jobId); err != nil {
+		slog.Error("Import interrupted, continue it with -resume", slog.Uint64("job", uint64(jobId)), slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	report, err := importService.GetJob(jobId)
+	if err != nil {
+		slog.Error("failed to read import report", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	if report.Status == repositories.ImportJobRunning {
+		slog.Error("Import job is running elsewhere or stopped less than two minutes ago, resume it later", slog.Uint64("job", uint64(jobId)))
+		os.Exit(1)
+	}
+	for _, file := range report.Files {
+		fmt.Printf("%s\t%s\t%s\n", file.Status, file.Path, file.Message)
+	}
+	slog.Info("Import finished",
+		slog.Uint64("job", uint64(jobId)),
+		slog.String("status", report.Status),
+		slog.Any("counts", report.Counts),
+	)
+	if report.Status != repositories.ImportJobDone {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"chords_app/internal/adapters/opensearch"
+	"chords_app/internal/config"
+	"chords_app/internal/database"
+	"chords_app/internal/repositories"
+	"chords_app/internal/services"
+	"flag"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", 500, "number of rows read from the database and sent per bulk request")
+	deleteOld := flag.Bool("delete-old", false, "delete the indices the alias pointed at before the swap")
+	flag.Parse()
+
+	cfg, err := config.SetupConfig()
+	if err != nil {
+		slog.Error("error in reading config:", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	db, err := database.SetupDatabase(&cfg.DB)
+	if err != nil {
+		slog.Error("error in setup database:", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	database.AutoMigrate(db)
+
+	opensearchClient, err := opensearch.CreateOpenSearchClient(&cfg.Opensearch)
+	if err != nil {
+		slog.Error("Failed to initialize opensearch client", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	opensearchAdapter := opensearch.NewOpenSearchAdapter(opensearchClient, cfg.Opensearch.IndexName)
+
+	reindexService := services.NewReindexService(
+		repositories.NewGormSongRepository(),
+		repositories.NewGormArtistRepository(),
+		repositories.NewGormOutboxRepository(),
+		opensearchAdapter,
+		db,
+	)
+
+	indexName, err := reindexService.Reindex(*batchSize, *deleteOld)
+	if err != nil {
+		slog.Error("Reindex failed", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	slog.Info("Reindex finished", slog.String("alias", cfg.Opensearch.IndexName), slog.String("index", indexName))
+}
@@ -0,0 +1,152 @@
+package opensearch
+
+import (
+	"chords_app/internal/chords"
+	"chords_app/internal/models"
+	"chords_app/internal/translit"
+	"strconv"
+	"strings"
+)
+
+// Document is a search document together with its ID in the index.
+type Document struct {
+	ID   string
+	Body map[string]interface{}
+}
+
+func SongDocument(song *models.Song, artists []models.Artist) Document {
+	artistNames := make([]string, 0, len(artists))
+	artistIds := make([]uint, 0, len(artists))
+	for _, artist := range artists {
+		artistNames = append(artistNames, artist.Name)
+		artistIds = append(artistIds, artist.ID)
+	}
+
+	tags := make([]string, 0, len(song.Tags))
+	for _, tag := range song.Tags {
+		tags = append(tags, tag.Name)
+	}
+
+	lyrics, sequence := songText(song.Content)
+	chordNames := make([]string, 0, len(sequence))
+	seen := make(map[string]bool)
+	for _, chord := range sequence {
+		if name := chord.String(); !seen[name] {
+			seen[name] = true
+			chordNames = append(chordNames, name)
+		}
+	}
+
+	body := map[string]interface{}{
+		"id":          song.ID,
+		"title":       song.Title,
+		"description": song.Description,
+		"lyrics":      lyrics,
+		"chords":      chordNames,
+		"artists":     artistNames,
+		"artist_ids":  artistIds,
+		"tags":        tags,
+		"type":        SongType,
+	}
+
+	// Latin variants of Cyrillic titles and names let "kino" find "Кино".
+	if translit.HasCyrillic(song.Title) {
+		body["title_latin"] = translit.ToLatin(song.Title)
+	}
+	if latinArtists := latinVariants(artistNames); len(latinArtists) > 0 {
+		body["artists_latin"] = latinArtists
+	}
+
+	if song.Key != "" {
+		body["key"] = song.Key
+		body["mode"] = song.Mode
+	} else if key, ok := chords.ContentKey(song.Content); ok {
+		// Songs saved before keys were stored.
+		key = key.Transpose(int(song.Capo))
+		body["key"] = key.String()
+		body["mode"] = key.Mode()
+	}
+
+	// Songs saved before instruments and tunings are played on guitar in
+	// standard tuning.
+	body["instrument"], body["tuning"] = song.Instrument, song.Tuning
+	if song.Instrument == "" {
+		body["instrument"] = string(chords.Guitar)
+	}
+	if song.Tuning == "" {
+		body["tuning"] = chords.StandardTuningName
+	}
+
+	// The progression is relative to the key the chords are written in,
+	// which differs from the concert key when the song uses a capo.
+	if key, ok := chords.DetectKey(sequence); ok {
+		body["progression"] = strings.Join(chords.ProgressionTokens(sequence, key), " ")
+	}
+	if song.UploadedBy != 0 {
+		body["uploaded_by"] = song.UploadedBy
+	}
+	if difficulty, ok := chords.EstimateDifficulty(sequence); ok {
+		body["difficulty"] = string(difficulty)
+	}
+
+	return Document{
+		ID:   documentId(SongType, song.ID),
+		Body: body,
+	}
+}
+
+func ArtistDocument(artist *models.Artist) Document {
+	body := map[string]interface{}{
+		"id":          artist.ID,
+		"name":        artist.Name,
+		"description": artist.Description,
+		"type":        ArtistType,
+	}
+	if translit.HasCyrillic(artist.Name) {
+		body["name_latin"] = translit.ToLatin(artist.Name)
+	}
+
+	return Document{
+		ID:   documentId(ArtistType, artist.ID),
+		Body: body,
+	}
+}
+
+// latinVariants transliterates the values written in Cyrillic.
+func latinVariants(values []string) []string {
+	variants := make([]string, 0, len(values))
+	for _, value := range values {
+		if translit.HasCyrillic(value) {
+			variants = append(variants, translit.ToLatin(value))
+		}
+	}
+	return variants
+}
+
+func documentId(objType string, objId uint) string {
+	return objType + "_" + strconv.FormatUint(uint64(objId), 10)
+}
+
+// songText returns the lyrics of the content, without chords,
+// directives, comments and tab sections, and its chords in order. Content
+// stored before it was validated is indexed as written, with the chords
+// chords.ExtractChords finds in it.
+func songText(content string) (string, []chords.Chord) {
+	sheet, err := chords.ParseChordPro(content)
+	if err != nil {
+		return content, chords.ExtractChords(content)
+	}
+
+	lyrics := make([]string, 0)
+	for _, section := range sheet.Sections {
+		for _, line := range section.Lines {
+			if line.Kind != chords.LyricsLine {
+				continue
+			}
+			if text := strings.Join(strings.Fields(line.Text), " "); text != "" {
+				lyrics = append(lyrics, text)
+			}
+		}
+	}
+	return strings.Join(lyrics, "\n"), sheet.Chords()
+}
@@ -0,0 +1,68 @@
+package opensearch
+
+import (
+	"testing"
+
+	"chords_app/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSongDocument_SplitsLyricsAndChords(t *testing.T) {
+	song := models.Song{
+		Title: "Wonderwall",
+		Content: "{title: Wonderwall}\n" +
+			"[Em7]Today is [G]gonna be the day\n" +
+			"\n" +
+			"{comment: Chorus}\n" +
+			"[Cadd9]And [Em7]all the [G]roads\n" +
+			"{start_of_tab: Riff}\n" +
+			"e|--0--3--|\n" +
+			"{end_of_tab}\n" +
+			"Ёлки [C#m7b5]палки",
+	}
+
+	song.UploadedBy = 3
+	song.Tags = []models.Tag{{Name: "britpop"}}
+
+	oasis := models.Artist{Name: "Oasis"}
+	oasis.ID = 5
+	doc := SongDocument(&song, []models.Artist{oasis})
+
+	assert.Equal(t, "song_0", doc.ID)
+	assert.Equal(t, "Today is gonna be the day\nAnd all the roads\nЁлки палки", doc.Body["lyrics"])
+	assert.Equal(t, []string{"Em7", "G", "Cadd9", "C#m7b5"}, doc.Body["chords"])
+	assert.Equal(t, []string{"Oasis"}, doc.Body["artists"])
+	assert.Equal(t, []uint{5}, doc.Body["artist_ids"])
+	assert.Equal(t, []string{"britpop"}, doc.Body["tags"])
+	assert.Equal(t, uint(3), doc.Body["uploaded_by"])
+	assert.Equal(t, "medium", doc.Body["difficulty"])
+	assert.Equal(t, "guitar", doc.Body["instrument"], "expected songs without an instrument to be on guitar")
+	assert.Equal(t, "standard", doc.Body["tuning"])
+	assert.NotContains(t, doc.Body, "content")
+}
+
+func TestSongDocument_ChordsMatchTheParser(t *testing.T) {
+	song := models.Song{Content: "# source comment\n[*Riff]Hey [N.C.]you [Hm]there [Em]now"}
+
+	doc := SongDocument(&song, nil)
+	assert.Equal(t, "Hey you there now", doc.Body["lyrics"])
+	assert.Equal(t, []string{"Bm", "Em"}, doc.Body["chords"], "expected annotations to be left out and chords spelled as parsed")
+
+	// Content stored before it was validated.
+	song.Content = "{title: Broken\n[Am]la [Xyz]la [C]la"
+	doc = SongDocument(&song, nil)
+	assert.Equal(t, song.Content, doc.Body["lyrics"])
+	assert.Equal(t, []string{"Am", "C"}, doc.Body["chords"])
+}
+
+func TestDocuments_StoreLatinVariantsOfCyrillicNames(t *testing.T) {
+	song := models.Song{Title: "Группа крови"}
+	doc := SongDocument(&song, []models.Artist{{Name: "Кино"}, {Name: "Oasis"}})
+
+	assert.Equal(t, "Gruppa krovi", doc.Body["title_latin"])
+	assert.Equal(t, []string{"Kino"}, doc.Body["artists_latin"])
+
+	doc = ArtistDocument(&models.Artist{Name: "Oasis"})
+	assert.NotContains(t, doc.Body, "name_latin", "expected no variant for Latin names")
+}
@@ -0,0 +1,247 @@
+package opensearch
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Facet names returned with search results, mapped to the document
+// fields they aggregate.
+const (
+	FacetArtist     = "artist"
+	FacetKey        = "key"
+	FacetMode       = "mode"
+	FacetInstrument = "instrument"
+	FacetTuning     = "tuning"
+	FacetDifficulty = "difficulty"
+	FacetTag        = "tag"
+	FacetUploader   = "uploader"
+)
+
+var facetFields = map[string]string{
+	FacetArtist:     "artist_ids",
+	FacetKey:        "key",
+	FacetMode:       "mode",
+	FacetInstrument: "instrument",
+	FacetTuning:     "tuning",
+	FacetDifficulty: "difficulty",
+	FacetTag:        "tags",
+	FacetUploader:   "uploaded_by",
+}
+
+const maxFacetBuckets = 20
+
+// SearchFilters narrows a search to songs matching every set field.
+type SearchFilters struct {
+	ArtistIds  []uint
+	Key        string
+	Mode       string
+	Instrument string
+	Tuning     string
+	Difficulty string
+	Tag        string
+	UploadedBy uint
+}
+
+func (f SearchFilters) IsEmpty() bool {
+	return len(f.ArtistIds) == 0 && f.Key == "" && f.Mode == "" && f.Instrument == "" && f.Tuning == "" &&
+		f.Difficulty == "" && f.Tag == "" && f.UploadedBy == 0
+}
+
+type FacetBucket struct {
+	Value string
+	Count uint
+}
+
+// SearchResponse is a page of search hits with facet counts over all hits.
+type SearchResponse struct {
+	Hits   []QueryResult
+	Facets map[string][]FacetBucket
+}
+
+// filterClauses builds the bool filter for the filters. Any filter limits
+// the search to songs since artists do not carry the filtered fields.
+func filterClauses(filters SearchFilters) []interface{} {
+	clauses := make([]interface{}, 0)
+	if filters.IsEmpty() {
+		return clauses
+	}
+
+	term := func(field string, value interface{}) map[string]interface{} {
+		return map[string]interface{}{"term": map[string]interface{}{field: value}}
+	}
+
+	clauses = append(clauses, term("type", SongType))
+	if len(filters.ArtistIds) > 0 {
+		clauses = append(clauses, map[string]interface{}{
+			"terms": map[string]interface{}{"artist_ids": filters.ArtistIds},
+		})
+	}
+	if filters.Key != "" {
+		clauses = append(clauses, term("key", filters.Key))
+	}
+	if filters.Mode != "" {
+		clauses = append(clauses, term("mode", filters.Mode))
+	}
+	if filters.Instrument != "" {
+		clauses = append(clauses, term("instrument", filters.Instrument))
+	}
+	if filters.Tuning != "" {
+		clauses = append(clauses, term("tuning", filters.Tuning))
+	}
+	if filters.Difficulty != "" {
+		clauses = append(clauses, term("difficulty", filters.Difficulty))
+	}
+	if filters.Tag != "" {
+		clauses = append(clauses, term("tags", filters.Tag))
+	}
+	if filters.UploadedBy != 0 {
+		clauses = append(clauses, term("uploaded_by", filters.UploadedBy))
+	}
+	return clauses
+}
+
+func facetAggregations() map[string]interface{} {
+	aggregations := make(map[string]interface{}, len(facetFields))
+	for facet, field := range facetFields {
+		aggregations[facet] = map[string]interface{}{
+			"terms": map[string]interface{}{"field": field, "size": maxFacetBuckets},
+		}
+	}
+	return aggregations
+}
+
+type aggregationResult struct {
+	Buckets []struct {
+		Key      interface{} `json:"key"`
+		DocCount uint        `json:"doc_count"`
+	} `json:"buckets"`
+}
+
+func parseFacets(aggregations map[string]aggregationResult) map[string][]FacetBucket {
+	facets := make(map[string][]FacetBucket, len(facetFields))
+	for facet := range facetFields {
+		buckets := make([]FacetBucket, 0)
+		for _, bucket := range aggregations[facet].Buckets {
+			buckets = append(buckets, FacetBucket{Value: bucketValue(bucket.Key), Count: bucket.DocCount})
+		}
+		facets[facet] = buckets
+	}
+	return facets
+}
+
+// bucketValue formats a terms bucket key. Keys of numeric fields are
+// decoded as float64.
+func bucketValue(key interface{}) string {
+	if number, ok := key.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(key)
+}
+
+// countFacets counts facet values over document bodies the way the terms
+// aggregations do: by document count, largest first, ties by value.
+func countFacets(bodies []map[string]interface{}) map[string][]FacetBucket {
+	facets := make(map[string][]FacetBucket, len(facetFields))
+	for facet, field := range facetFields {
+		counts := make(map[string]uint)
+		for _, body := range bodies {
+			for _, value := range fieldValues(body[field]) {
+				counts[value]++
+			}
+		}
+
+		buckets := make([]FacetBucket, 0, len(counts))
+		for value, count := range counts {
+			buckets = append(buckets, FacetBucket{value, count})
+		}
+		sort.Slice(buckets, func(i, j int) bool {
+			if buckets[i].Count != buckets[j].Count {
+				return buckets[i].Count > buckets[j].Count
+			}
+			return buckets[i].Value < buckets[j].Value
+		})
+		if len(buckets) > maxFacetBuckets {
+			buckets = buckets[:maxFacetBuckets]
+		}
+		facets[facet] = buckets
+	}
+	return facets
+}
+
+// matchesFilters reports whether a document body satisfies the filters.
+func matchesFilters(body map[string]interface{}, filters SearchFilters) bool {
+	if filters.IsEmpty() {
+		return true
+	}
+	if body["type"] != SongType {
+		return false
+	}
+
+	if len(filters.ArtistIds) > 0 {
+		found := false
+		for _, artistId := range filters.ArtistIds {
+			if containsValue(body["artist_ids"], strconv.FormatUint(uint64(artistId), 10)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filters.Key != "" && !containsValue(body["key"], filters.Key) {
+		return false
+	}
+	if filters.Mode != "" && !containsValue(body["mode"], filters.Mode) {
+		return false
+	}
+	if filters.Instrument != "" && !containsValue(body["instrument"], filters.Instrument) {
+		return false
+	}
+	if filters.Tuning != "" && !containsValue(body["tuning"], filters.Tuning) {
+		return false
+	}
+	if filters.Difficulty != "" && !containsValue(body["difficulty"], filters.Difficulty) {
+		return false
+	}
+	if filters.Tag != "" && !containsValue(body["tags"], filters.Tag) {
+		return false
+	}
+	if filters.UploadedBy != 0 && !containsValue(body["uploaded_by"], strconv.FormatUint(uint64(filters.UploadedBy), 10)) {
+		return false
+	}
+	return true
+}
+
+func containsValue(field interface{}, value string) bool {
+	for _, v := range fieldValues(field) {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValues formats a document field as a list of keyword values.
+func fieldValues(field interface{}) []string {
+	switch v := field.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case uint:
+		return []string{strconv.FormatUint(uint64(v), 10)}
+	case []uint:
+		values := make([]string, 0, len(v))
+		for _, n := range v {
+			values = append(values, strconv.FormatUint(uint64(n), 10))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
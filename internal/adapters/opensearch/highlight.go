@@ -0,0 +1,142 @@
+package opensearch
+
+import (
+	"html"
+	"strings"
+)
+
+// Matched terms in highlight snippets are wrapped in these tags. Snippet
+// text is HTML escaped so clients can render it as is.
+const (
+	HighlightPreTag  = "<em>"
+	HighlightPostTag = "</em>"
+)
+
+const (
+	lyricsSnippetSize      = 120
+	maxLyricsSnippets      = 2
+	descriptionSnippetSize = 160
+)
+
+// highlightedFields are the document fields search results are
+// highlighted on. Analyzed subfields are highlighted as well, because a
+// stemmed or transliterated match only shows up on the subfield, and are
+// merged back into their base field by parseHighlights.
+var highlightedFields = []string{"title", "name", "description", "lyrics"}
+
+func highlightRequest() map[string]interface{} {
+	fields := make(map[string]interface{})
+	for _, field := range highlightedFields {
+		options := map[string]interface{}{}
+		switch field {
+		case "title", "name":
+			// Titles and names are short, highlight them whole.
+			options["number_of_fragments"] = 0
+		case "description":
+			options["fragment_size"] = descriptionSnippetSize
+			options["number_of_fragments"] = 1
+		case "lyrics":
+			options["fragment_size"] = lyricsSnippetSize
+			options["number_of_fragments"] = maxLyricsSnippets
+		}
+		for _, name := range []string{field, field + ".ru", field + ".en"} {
+			fields[name] = options
+		}
+	}
+
+	return map[string]interface{}{
+		"pre_tags":  []string{HighlightPreTag},
+		"post_tags": []string{HighlightPostTag},
+		"encoder":   "html",
+		"fields":    fields,
+	}
+}
+
+// parseHighlights merges subfield highlights into their base field and
+// cuts lyric fragments down to the lines containing a match.
+func parseHighlights(raw map[string][]string) map[string][]string {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	highlights := make(map[string][]string)
+	for _, field := range highlightedFields {
+		for _, name := range []string{field, field + ".ru", field + ".en"} {
+			fragments := raw[name]
+			if len(fragments) == 0 {
+				continue
+			}
+			if field == "lyrics" {
+				fragments = matchedLines(fragments, maxLyricsSnippets)
+			}
+			if len(fragments) > 0 {
+				highlights[field] = fragments
+				break
+			}
+		}
+	}
+
+	if len(highlights) == 0 {
+		return nil
+	}
+	return highlights
+}
+
+// matchedLines returns up to limit distinct lines of the fragments that
+// contain a highlighted term.
+func matchedLines(fragments []string, limit int) []string {
+	lines := make([]string, 0, limit)
+	seen := make(map[string]bool)
+	for _, fragment := range fragments {
+		for _, line := range strings.Split(fragment, "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.Contains(line, HighlightPreTag) || seen[line] {
+				continue
+			}
+			seen[line] = true
+			lines = append(lines, line)
+			if len(lines) == limit {
+				return lines
+			}
+		}
+	}
+	return lines
+}
+
+// markTerms HTML escapes text and wraps case-insensitive occurrences of
+// the terms in highlight tags. ok is false when no term occurs.
+func markTerms(text string, terms []string) (marked string, ok bool) {
+	lower := strings.ToLower(text)
+	if len(lower) != len(text) {
+		// Lowercasing changed byte offsets, fall back to exact matching.
+		lower = text
+	}
+
+	var builder strings.Builder
+	position := 0
+	for position < len(text) {
+		matchStart, matchLen := -1, 0
+		for _, term := range terms {
+			if term == "" {
+				continue
+			}
+			index := strings.Index(lower[position:], term)
+			if index >= 0 && (matchStart < 0 || position+index < matchStart) {
+				matchStart, matchLen = position+index, len(term)
+			}
+		}
+		if matchStart < 0 {
+			break
+		}
+
+		builder.WriteString(html.EscapeString(text[position:matchStart]))
+		builder.WriteString(HighlightPreTag)
+		builder.WriteString(html.EscapeString(text[matchStart : matchStart+matchLen]))
+		builder.WriteString(HighlightPostTag)
+		position = matchStart + matchLen
+		ok = true
+	}
+	builder.WriteString(html.EscapeString(text[position:]))
+
+	return builder.String(), ok
+}
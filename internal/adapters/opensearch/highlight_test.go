@@ -0,0 +1,33 @@
+package opensearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHighlights_MergesSubfieldsAndKeepsMatchedLines(t *testing.T) {
+	highlights := parseHighlights(map[string][]string{
+		"title.ru": {"<em>Группа</em> крови"},
+		"lyrics": {
+			"Тёплое место, но улицы ждут\nОтпечатков наших <em>ног</em>",
+			"Звёздная пыль на сапогах\nОтпечатков наших <em>ног</em>",
+		},
+		"lyrics.ru": {"ignored, base field takes precedence"},
+		"name.en":   {"<em>Kino</em>"},
+	})
+
+	assert.Equal(t, []string{"<em>Группа</em> крови"}, highlights["title"])
+	assert.Equal(t, []string{"Отпечатков наших <em>ног</em>"}, highlights["lyrics"])
+	assert.Equal(t, []string{"<em>Kino</em>"}, highlights["name"])
+	assert.NotContains(t, highlights, "description")
+}
+
+func TestMarkTerms(t *testing.T) {
+	marked, ok := markTerms("Today is <gonna> be the DAY", []string{"day", "today"})
+	assert.True(t, ok)
+	assert.Equal(t, "<em>Today</em> is &lt;gonna&gt; be the <em>DAY</em>", marked)
+
+	_, ok = markTerms("And all the roads", []string{"day"})
+	assert.False(t, ok)
+}
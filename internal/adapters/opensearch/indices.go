@@ -0,0 +1,278 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/opensearchapi"
+)
+
+// IndexManager manages the versioned indices behind the search alias.
+type IndexManager interface {
+	NewVersionedIndexName() string
+	CreateIndex(name string) error
+	BulkIndex(indexName string, docs []Document) error
+	RefreshIndex(name string) error
+	SwapAlias(newIndex string) ([]string, error)
+	DeleteIndices(names []string) error
+}
+
+// NewVersionedIndexName returns a fresh index name for the alias. The
+// configured index name is used as an alias pointing at a versioned index,
+// so the index can be rebuilt and swapped in without downtime.
+func (oa *OpenSearchAdapter) NewVersionedIndexName() string {
+	return fmt.Sprintf("%s_v%s", oa.indexName, time.Now().UTC().Format("20060102150405"))
+}
+
+// EnsureIndex creates a versioned index with the explicit mapping and
+// points the alias at it, unless the alias or an index with its name
+// already exists.
+func (oa *OpenSearchAdapter) EnsureIndex() error {
+	aliased, err := oa.aliasedIndices()
+	if err != nil {
+		return err
+	}
+	if len(aliased) > 0 {
+		return nil
+	}
+
+	exists, err := oa.indexExists(oa.indexName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		slog.Warn("Search index is not behind an alias, run cmd/reindex to apply the current mapping", slog.String("index", oa.indexName))
+		return nil
+	}
+
+	indexName := oa.NewVersionedIndexName()
+	if err := oa.CreateIndex(indexName); err != nil {
+		return err
+	}
+	_, err = oa.SwapAlias(indexName)
+	return err
+}
+
+// CreateIndex creates an index with the settings and mappings from
+// indexDefinition.
+func (oa *OpenSearchAdapter) CreateIndex(name string) error {
+	request := opensearchapi.IndicesCreateRequest{
+		Index: name,
+		Body:  strings.NewReader(indexDefinition),
+	}
+
+	response, err := request.Do(context.Background(), oa.client)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		slog.Error("Error creating index", slog.String("index", name), slog.String("response", response.String()))
+		return fmt.Errorf("error creating index %s", name)
+	}
+	return nil
+}
+
+// BulkIndex writes documents into the given index with a single bulk
+// request.
+func (oa *OpenSearchAdapter) BulkIndex(indexName string, docs []Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, doc := range docs {
+		action := map[string]interface{}{
+			"index": map[string]interface{}{"_index": indexName, "_id": doc.ID},
+		}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+		if err := encoder.Encode(doc.Body); err != nil {
+			return err
+		}
+	}
+
+	request := opensearchapi.BulkRequest{
+		Index: indexName,
+		Body:  &body,
+	}
+
+	response, err := request.Do(context.Background(), oa.client)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		slog.Error("Error in bulk request", slog.String("index", indexName), slog.String("response", response.String()))
+		return fmt.Errorf("error bulk indexing into %s", indexName)
+	}
+
+	var bulkResponse struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&bulkResponse); err != nil {
+		return err
+	}
+
+	if bulkResponse.Errors {
+		for _, item := range bulkResponse.Items {
+			for _, result := range item {
+				if len(result.Error) > 0 {
+					return fmt.Errorf("error bulk indexing document ID %s: %s", result.ID, result.Error)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (oa *OpenSearchAdapter) RefreshIndex(name string) error {
+	request := opensearchapi.IndicesRefreshRequest{
+		Index: []string{name},
+	}
+
+	response, err := request.Do(context.Background(), oa.client)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		return fmt.Errorf("error refreshing index %s: %s", name, response.String())
+	}
+	return nil
+}
+
+// SwapAlias atomically points the alias at newIndex and returns the
+// indices it pointed at before. A concrete index occupying the alias name
+// (left over from before aliases were used) is removed in the same request.
+func (oa *OpenSearchAdapter) SwapAlias(newIndex string) ([]string, error) {
+	previous, err := oa.aliasedIndices()
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make([]map[string]interface{}, 0, len(previous)+2)
+	for _, index := range previous {
+		actions = append(actions, map[string]interface{}{
+			"remove": map[string]interface{}{"index": index, "alias": oa.indexName},
+		})
+	}
+
+	if len(previous) == 0 {
+		concrete, err := oa.indexExists(oa.indexName)
+		if err != nil {
+			return nil, err
+		}
+		if concrete {
+			slog.Warn("Replacing concrete index with alias", slog.String("index", oa.indexName))
+			actions = append(actions, map[string]interface{}{
+				"remove_index": map[string]interface{}{"index": oa.indexName},
+			})
+		}
+	}
+
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]interface{}{"index": newIndex, "alias": oa.indexName},
+	})
+
+	bodyBytes, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return nil, err
+	}
+
+	request := opensearchapi.IndicesUpdateAliasesRequest{
+		Body: bytes.NewReader(bodyBytes),
+	}
+
+	response, err := request.Do(context.Background(), oa.client)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		slog.Error("Error swapping alias", slog.String("alias", oa.indexName), slog.String("response", response.String()))
+		return nil, fmt.Errorf("error pointing alias %s at %s", oa.indexName, newIndex)
+	}
+	return previous, nil
+}
+
+func (oa *OpenSearchAdapter) DeleteIndices(names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	request := opensearchapi.IndicesDeleteRequest{
+		Index: names,
+	}
+
+	response, err := request.Do(context.Background(), oa.client)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		return fmt.Errorf("error deleting indices %v: %s", names, response.String())
+	}
+	return nil
+}
+
+func (oa *OpenSearchAdapter) aliasedIndices() ([]string, error) {
+	request := opensearchapi.IndicesGetAliasRequest{
+		Name: []string{oa.indexName},
+	}
+
+	response, err := request.Do(context.Background(), oa.client)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return []string{}, nil
+	}
+	if response.IsError() {
+		return nil, fmt.Errorf("error reading alias %s: %s", oa.indexName, response.String())
+	}
+
+	var aliases map[string]interface{}
+	if err := json.NewDecoder(response.Body).Decode(&aliases); err != nil {
+		return nil, err
+	}
+
+	indices := make([]string, 0, len(aliases))
+	for index := range aliases {
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+func (oa *OpenSearchAdapter) indexExists(name string) (bool, error) {
+	request := opensearchapi.IndicesExistsRequest{
+		Index: []string{name},
+	}
+
+	response, err := request.Do(context.Background(), oa.client)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode == http.StatusOK, nil
+}
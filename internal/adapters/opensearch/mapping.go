@@ -0,0 +1,156 @@
+package opensearch
+
+// indexDefinition is used for every index created by the adapter. Text
+// fields get Russian and English stemmed subfields since the catalogue is
+// bilingual, and titles and names get an edge-ngram subfield for prefix
+// search. Lyrics are indexed with chords already stripped (see
+// SongDocument), strip_chords only guards against inline chords that slip
+// through.
+const indexDefinition = `{
+  "settings": {
+    "analysis": {
+      "char_filter": {
+        "strip_chords": {
+          "type": "pattern_replace",
+          "pattern": "\\[[^\\]]*\\]",
+          "replacement": ""
+        },
+        "yo_to_ye": {
+          "type": "mapping",
+          "mappings": ["ё => е", "Ё => Е"]
+        }
+      },
+      "filter": {
+        "russian_stop": {"type": "stop", "stopwords": "_russian_"},
+        "russian_stemmer": {"type": "stemmer", "language": "russian"},
+        "english_stop": {"type": "stop", "stopwords": "_english_"},
+        "english_stemmer": {"type": "stemmer", "language": "english"},
+        "english_possessive_stemmer": {"type": "stemmer", "language": "possessive_english"},
+        "prefix_ngram": {"type": "edge_ngram", "min_gram": 1, "max_gram": 20}
+      },
+      "analyzer": {
+        "text_default": {
+          "type": "custom",
+          "char_filter": ["yo_to_ye"],
+          "tokenizer": "standard",
+          "filter": ["lowercase"]
+        },
+        "text_ru": {
+          "type": "custom",
+          "char_filter": ["yo_to_ye"],
+          "tokenizer": "standard",
+          "filter": ["lowercase", "russian_stop", "russian_stemmer"]
+        },
+        "text_en": {
+          "type": "custom",
+          "tokenizer": "standard",
+          "filter": ["english_possessive_stemmer", "lowercase", "english_stop", "english_stemmer"]
+        },
+        "lyrics_default": {
+          "type": "custom",
+          "char_filter": ["strip_chords", "yo_to_ye"],
+          "tokenizer": "standard",
+          "filter": ["lowercase"]
+        },
+        "lyrics_ru": {
+          "type": "custom",
+          "char_filter": ["strip_chords", "yo_to_ye"],
+          "tokenizer": "standard",
+          "filter": ["lowercase", "russian_stop", "russian_stemmer"]
+        },
+        "lyrics_en": {
+          "type": "custom",
+          "char_filter": ["strip_chords"],
+          "tokenizer": "standard",
+          "filter": ["english_possessive_stemmer", "lowercase", "english_stop", "english_stemmer"]
+        },
+        "prefix_index": {
+          "type": "custom",
+          "char_filter": ["yo_to_ye"],
+          "tokenizer": "standard",
+          "filter": ["lowercase", "prefix_ngram"]
+        },
+        "prefix_search": {
+          "type": "custom",
+          "char_filter": ["yo_to_ye"],
+          "tokenizer": "standard",
+          "filter": ["lowercase"]
+        }
+      }
+    }
+  },
+  "mappings": {
+    "dynamic": false,
+    "properties": {
+      "id": {"type": "long"},
+      "type": {"type": "keyword"},
+      "title": {
+        "type": "text",
+        "analyzer": "text_default",
+        "fields": {
+          "ru": {"type": "text", "analyzer": "text_ru"},
+          "en": {"type": "text", "analyzer": "text_en"},
+          "prefix": {"type": "text", "analyzer": "prefix_index", "search_analyzer": "prefix_search"}
+        }
+      },
+      "name": {
+        "type": "text",
+        "analyzer": "text_default",
+        "fields": {
+          "ru": {"type": "text", "analyzer": "text_ru"},
+          "en": {"type": "text", "analyzer": "text_en"},
+          "prefix": {"type": "text", "analyzer": "prefix_index", "search_analyzer": "prefix_search"}
+        }
+      },
+      "title_latin": {
+        "type": "text",
+        "analyzer": "text_default",
+        "fields": {
+          "prefix": {"type": "text", "analyzer": "prefix_index", "search_analyzer": "prefix_search"}
+        }
+      },
+      "name_latin": {
+        "type": "text",
+        "analyzer": "text_default",
+        "fields": {
+          "prefix": {"type": "text", "analyzer": "prefix_index", "search_analyzer": "prefix_search"}
+        }
+      },
+      "artists_latin": {"type": "text", "analyzer": "text_default"},
+      "artists": {
+        "type": "text",
+        "analyzer": "text_default",
+        "fields": {
+          "ru": {"type": "text", "analyzer": "text_ru"},
+          "en": {"type": "text", "analyzer": "text_en"}
+        }
+      },
+      "description": {
+        "type": "text",
+        "analyzer": "text_default",
+        "fields": {
+          "ru": {"type": "text", "analyzer": "text_ru"},
+          "en": {"type": "text", "analyzer": "text_en"}
+        }
+      },
+      "lyrics": {
+        "type": "text",
+        "analyzer": "lyrics_default",
+        "fields": {
+          "ru": {"type": "text", "analyzer": "lyrics_ru"},
+          "en": {"type": "text", "analyzer": "lyrics_en"}
+        }
+      },
+      "artist_ids": {"type": "long"},
+      "tags": {"type": "keyword"},
+      "uploaded_by": {"type": "long"},
+      "chords": {"type": "keyword"},
+      "key": {"type": "keyword"},
+      "mode": {"type": "keyword"},
+      "instrument": {"type": "keyword"},
+      "tuning": {"type": "keyword"},
+      "difficulty": {"type": "keyword"},
+      "progression": {"type": "text", "analyzer": "whitespace"}
+    }
+  }
+}`
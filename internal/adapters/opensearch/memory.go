@@ -0,0 +1,288 @@
+package opensearch
+
+import (
+	"chords_app/internal/models"
+	"chords_app/internal/translit"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// InMemoryAdapter is an in-process SearchAdapter used where a live
+// OpenSearch cluster is not available (tests, local development).
+// It scores documents by counting query terms, weighting title and
+// name matches the same way the OpenSearch multi_match query does.
+type InMemoryAdapter struct {
+	mu   sync.RWMutex
+	docs map[string]memoryDocument
+}
+
+type memoryDocument struct {
+	objType string
+	objId   uint
+	body    map[string]interface{}
+}
+
+var memoryFieldWeights = map[string]float32{
+	"title":         3,
+	"title_latin":   3,
+	"name":          3,
+	"name_latin":    3,
+	"artists":       2,
+	"artists_latin": 2,
+	"lyrics":        1,
+	"description":   1,
+}
+
+// memoryNameFields are also matched against the Latin transliteration of
+// a Cyrillic query.
+var memoryNameFields = map[string]bool{
+	"title": true, "title_latin": true, "name": true, "name_latin": true, "artists": true, "artists_latin": true,
+}
+
+func NewInMemoryAdapter() *InMemoryAdapter {
+	return &InMemoryAdapter{docs: make(map[string]memoryDocument)}
+}
+
+func (ma *InMemoryAdapter) IndexSong(song *models.Song, artists []models.Artist) error {
+	ma.put(SongType, song.ID, SongDocument(song, artists))
+	return nil
+}
+
+func (ma *InMemoryAdapter) IndexArtist(artist *models.Artist) error {
+	ma.put(ArtistType, artist.ID, ArtistDocument(artist))
+	return nil
+}
+
+func (ma *InMemoryAdapter) DeleteSong(songId uint) error {
+	ma.delete(SongType, songId)
+	return nil
+}
+
+func (ma *InMemoryAdapter) DeleteArtist(artistId uint) error {
+	ma.delete(ArtistType, artistId)
+	return nil
+}
+
+// Search scores documents passing the filters by query term counts and
+// counts facets over every match. An empty query matches every document
+// passing the filters.
+func (ma *InMemoryAdapter) Search(query string, filters SearchFilters, limit, offset uint) (*SearchResponse, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	latinTerms := make([]string, 0)
+	if translit.HasCyrillic(query) {
+		latinTerms = strings.Fields(strings.ToLower(translit.ToLatin(query)))
+	}
+
+	ma.mu.RLock()
+	results := make([]QueryResult, 0)
+	matched := make([]map[string]interface{}, 0)
+	for _, doc := range ma.docs {
+		if !matchesFilters(doc.body, filters) {
+			continue
+		}
+
+		score := float32(1)
+		if len(terms) > 0 {
+			score = 0
+			for field, weight := range memoryFieldWeights {
+				value := strings.ToLower(memoryFieldText(doc.body[field]))
+				for _, term := range terms {
+					score += float32(strings.Count(value, term)) * weight
+				}
+				if memoryNameFields[field] {
+					for _, term := range latinTerms {
+						score += float32(strings.Count(value, term)) * weight
+					}
+				}
+			}
+		}
+		if score > 0 {
+			results = append(results, QueryResult{
+				Score:      score,
+				ObjType:    doc.objType,
+				ObjId:      doc.objId,
+				Highlights: memoryHighlights(doc.body, terms),
+			})
+			matched = append(matched, doc.body)
+		}
+	}
+	ma.mu.RUnlock()
+
+	return &SearchResponse{
+		Hits:   rankResults(results, limit, offset),
+		Facets: countFacets(matched),
+	}, nil
+}
+
+// SearchProgression scores songs by how much of their progression the
+// phrases cover.
+func (ma *InMemoryAdapter) SearchProgression(phrases []WeightedPhrase, limit, offset uint) ([]QueryResult, error) {
+	ma.mu.RLock()
+	results := make([]QueryResult, 0)
+	for _, doc := range ma.docs {
+		tokens := strings.Fields(memoryFieldText(doc.body["progression"]))
+		if doc.objType != SongType || len(tokens) == 0 {
+			continue
+		}
+
+		var score float32
+		for _, phrase := range phrases {
+			phraseTokens := strings.Fields(phrase.Phrase)
+			occurrences := countSubsequence(tokens, phraseTokens)
+			score += phrase.Boost * float32(occurrences*len(phraseTokens)) / float32(len(tokens))
+		}
+		if score > 0 {
+			results = append(results, QueryResult{Score: score, ObjType: doc.objType, ObjId: doc.objId})
+		}
+	}
+	ma.mu.RUnlock()
+
+	return rankResults(results, limit, offset), nil
+}
+
+func (ma *InMemoryAdapter) Suggest(prefix string, limit uint) ([]Suggestion, error) {
+	terms := strings.Fields(strings.ToLower(prefix))
+	latinTerms := strings.Fields(strings.ToLower(translit.ToLatin(prefix)))
+
+	ma.mu.RLock()
+	suggestions := make([]Suggestion, 0)
+	for _, doc := range ma.docs {
+		field := "title"
+		if doc.objType == ArtistType {
+			field = "name"
+		}
+		text := memoryFieldText(doc.body[field])
+		words := strings.Fields(strings.ToLower(text + " " + memoryFieldText(doc.body[field+"_latin"])))
+		if len(terms) > 0 && (matchesPrefixes(words, terms) || matchesPrefixes(words, latinTerms)) {
+			suggestions = append(suggestions, Suggestion{ObjType: doc.objType, ObjId: doc.objId, Text: text})
+		}
+	}
+	ma.mu.RUnlock()
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Text != suggestions[j].Text {
+			return suggestions[i].Text < suggestions[j].Text
+		}
+		return suggestions[i].ObjId < suggestions[j].ObjId
+	})
+
+	if limit < uint(len(suggestions)) {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+func (ma *InMemoryAdapter) put(objType string, objId uint, doc Document) {
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
+	ma.docs[doc.ID] = memoryDocument{objType, objId, doc.Body}
+}
+
+func (ma *InMemoryAdapter) delete(objType string, objId uint) {
+	ma.mu.Lock()
+	defer ma.mu.Unlock()
+	delete(ma.docs, documentId(objType, objId))
+}
+
+func memoryFieldText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, " ")
+	default:
+		return ""
+	}
+}
+
+// memoryHighlights marks the terms in the highlighted fields, keeping
+// whole lines of lyrics like parseHighlights does.
+func memoryHighlights(body map[string]interface{}, terms []string) map[string][]string {
+	highlights := make(map[string][]string)
+	for _, field := range highlightedFields {
+		limit := 1
+		if field == "lyrics" {
+			limit = maxLyricsSnippets
+		}
+
+		snippets := make([]string, 0, limit)
+		for _, line := range strings.Split(memoryFieldText(body[field]), "\n") {
+			if marked, ok := markTerms(strings.TrimSpace(line), terms); ok {
+				snippets = append(snippets, marked)
+				if len(snippets) == limit {
+					break
+				}
+			}
+		}
+		if len(snippets) > 0 {
+			highlights[field] = snippets
+		}
+	}
+
+	if len(highlights) == 0 {
+		return nil
+	}
+	return highlights
+}
+
+// matchesPrefixes reports whether every term is a prefix of some word.
+func matchesPrefixes(words, terms []string) bool {
+	for _, term := range terms {
+		found := false
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// rankResults orders results by descending score and applies pagination.
+func rankResults(results []QueryResult, limit, offset uint) []QueryResult {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].ObjType != results[j].ObjType {
+			return results[i].ObjType < results[j].ObjType
+		}
+		return results[i].ObjId < results[j].ObjId
+	})
+
+	if offset >= uint(len(results)) {
+		return []QueryResult{}
+	}
+	results = results[offset:]
+	if limit < uint(len(results)) {
+		results = results[:limit]
+	}
+	return results
+}
+
+// countSubsequence counts the positions where phrase occurs in tokens.
+func countSubsequence(tokens, phrase []string) int {
+	if len(phrase) == 0 {
+		return 0
+	}
+
+	count := 0
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		matched := true
+		for j := range phrase {
+			if tokens[i+j] != phrase[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			count++
+		}
+	}
+	return count
+}
@@ -4,13 +4,13 @@ import (
 	"bytes"
 	"chords_app/internal/config"
 	"chords_app/internal/models"
+	"chords_app/internal/translit"
 	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
-	"strconv"
 
 	"github.com/opensearch-project/opensearch-go"
 	"github.com/opensearch-project/opensearch-go/opensearchapi"
@@ -27,6 +27,21 @@ func CreateOpenSearchClient(config *config.Opensearch) (*opensearch.Client, erro
 	})
 }
 
+const (
+	SongType   = "song"
+	ArtistType = "artist"
+)
+
+type SearchAdapter interface {
+	IndexSong(song *models.Song, artists []models.Artist) error
+	IndexArtist(artist *models.Artist) error
+	DeleteSong(songId uint) error
+	DeleteArtist(artistId uint) error
+	Search(query string, filters SearchFilters, limit, offset uint) (*SearchResponse, error)
+	Suggest(prefix string, limit uint) ([]Suggestion, error)
+	SearchProgression(phrases []WeightedPhrase, limit, offset uint) ([]QueryResult, error)
+}
+
 type OpenSearchAdapter struct {
 	client    *opensearch.Client
 	indexName string
@@ -40,45 +55,100 @@ type QueryResult struct {
 	Score   float32
 	ObjType string
 	ObjId   uint
+	// Highlights maps a field name to snippets of it with the matched
+	// terms wrapped in HighlightPreTag and HighlightPostTag.
+	Highlights map[string][]string
 }
 
-func (oa *OpenSearchAdapter) IndexSong(song *models.Song) error {
-	body := map[string]interface{}{
-		"id":          song.ID,
-		"title":       song.Title,
-		"description": song.Description,
-		"content":     song.Content,
-		"type":        "song",
-	}
-	doc_id := "song_" + strconv.FormatUint(uint64(song.ID), 10)
-	return oa.indexDocument(doc_id, body)
+// WeightedPhrase is a space separated token phrase with a score boost.
+type WeightedPhrase struct {
+	Phrase string
+	Boost  float32
+}
+
+type Suggestion struct {
+	ObjType string
+	ObjId   uint
+	Text    string
+}
+
+func (oa *OpenSearchAdapter) IndexSong(song *models.Song, artists []models.Artist) error {
+	return oa.indexDocument(SongDocument(song, artists))
 }
 
 func (oa *OpenSearchAdapter) IndexArtist(artist *models.Artist) error {
-	body := map[string]interface{}{
-		"id":          artist.ID,
-		"name":        artist.Name,
-		"description": artist.Description,
-		"type":        "artist",
-	}
-	doc_id := "artist_" + strconv.FormatUint(uint64(artist.ID), 10)
-	return oa.indexDocument(doc_id, body)
+	return oa.indexDocument(ArtistDocument(artist))
 }
 
-func (oa *OpenSearchAdapter) Search(query string) ([]QueryResult, error) {
+func (oa *OpenSearchAdapter) DeleteSong(songId uint) error {
+	return oa.deleteDocument(documentId(SongType, songId))
+}
+
+func (oa *OpenSearchAdapter) DeleteArtist(artistId uint) error {
+	return oa.deleteDocument(documentId(ArtistType, artistId))
+}
+
+// Search runs a full text query narrowed by the filters and aggregates the
+// facets over all matching documents. An empty query matches every
+// document passing the filters.
+func (oa *OpenSearchAdapter) Search(query string, filters SearchFilters, limit, offset uint) (*SearchResponse, error) {
+	textQuery := map[string]interface{}{"match_all": map[string]interface{}{}}
+	if query != "" {
+		should := []interface{}{
+			map[string]interface{}{
+				"multi_match": map[string]interface{}{
+					"query": query,
+					"fields": []string{
+						"title^3",
+						"title.ru^3",
+						"title.en^3",
+						"title_latin^3",
+						"name^3",
+						"name.ru^3",
+						"name.en^3",
+						"name_latin^3",
+						"artists^2",
+						"artists.ru^2",
+						"artists.en^2",
+						"artists_latin^2",
+						"lyrics",
+						"lyrics.ru",
+						"lyrics.en",
+						"description",
+						"description.ru",
+						"description.en",
+					},
+					"fuzziness": "AUTO",
+				},
+			},
+		}
+		// A Cyrillic query is also matched in Latin against Latin names and
+		// the Latin variants of Cyrillic ones.
+		if translit.HasCyrillic(query) {
+			should = append(should, map[string]interface{}{
+				"multi_match": map[string]interface{}{
+					"query":     translit.ToLatin(query),
+					"fields":    []string{"title^3", "title_latin^3", "name^3", "name_latin^3", "artists^2", "artists_latin^2"},
+					"fuzziness": "AUTO",
+				},
+			})
+		}
+		textQuery = map[string]interface{}{
+			"bool": map[string]interface{}{"should": should, "minimum_should_match": 1},
+		}
+	}
+
 	searchBody := map[string]interface{}{
+		"from": offset,
+		"size": limit,
 		"query": map[string]interface{}{
-			"multi_match": map[string]interface{}{
-				"query": query,
-				"fields": []string{
-					"title^3",
-					"name^3",
-					"content",
-					"description",
-				},
-				"fuzziness": "AUTO",
+			"bool": map[string]interface{}{
+				"must":   textQuery,
+				"filter": filterClauses(filters),
 			},
 		},
+		"aggs":      facetAggregations(),
+		"highlight": highlightRequest(),
 		"sort": []interface{}{
 			map[string]interface{}{
 				"_score": map[string]interface{}{
@@ -88,6 +158,46 @@ func (oa *OpenSearchAdapter) Search(query string) ([]QueryResult, error) {
 		},
 	}
 
+	return oa.runSearch(searchBody)
+}
+
+// SearchProgression finds songs whose progression field contains any of
+// the phrases, scoring each match by its boost and phrase frequency.
+func (oa *OpenSearchAdapter) SearchProgression(phrases []WeightedPhrase, limit, offset uint) ([]QueryResult, error) {
+	should := make([]interface{}, 0, len(phrases))
+	for _, phrase := range phrases {
+		should = append(should, map[string]interface{}{
+			"match_phrase": map[string]interface{}{
+				"progression": map[string]interface{}{
+					"query": phrase.Phrase,
+					"boost": phrase.Boost,
+				},
+			},
+		})
+	}
+
+	searchBody := map[string]interface{}{
+		"from": offset,
+		"size": limit,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter":               []interface{}{map[string]interface{}{"term": map[string]interface{}{"type": SongType}}},
+				"should":               should,
+				"minimum_should_match": 1,
+			},
+		},
+	}
+
+	response, err := oa.runSearch(searchBody)
+	if err != nil {
+		return nil, err
+	}
+	return response.Hits, nil
+}
+
+// runSearch executes the search request. Facets are only set when the
+// request asked for the facet aggregations.
+func (oa *OpenSearchAdapter) runSearch(searchBody map[string]interface{}) (*SearchResponse, error) {
 	bodyBytes, err := json.Marshal(searchBody)
 	if err != nil {
 		return nil, err
@@ -104,6 +214,11 @@ func (oa *OpenSearchAdapter) Search(query string) ([]QueryResult, error) {
 	}
 	defer response.Body.Close()
 
+	if response.IsError() {
+		slog.Error("Error searching documents", slog.String("response", response.String()))
+		return nil, fmt.Errorf("error searching index %s", oa.indexName)
+	}
+
 	var searchResults struct {
 		Hits struct {
 			Hits []struct {
@@ -112,8 +227,10 @@ func (oa *OpenSearchAdapter) Search(query string) ([]QueryResult, error) {
 					ID   uint   `json:"id"`
 					Type string `json:"type"`
 				} `json:"_source"`
+				Highlight map[string][]string `json:"highlight"`
 			} `json:"hits"`
 		} `json:"hits"`
+		Aggregations map[string]aggregationResult `json:"aggregations"`
 	}
 
 	if err := json.NewDecoder(response.Body).Decode(&searchResults); err != nil {
@@ -123,24 +240,119 @@ func (oa *OpenSearchAdapter) Search(query string) ([]QueryResult, error) {
 	results := make([]QueryResult, 0, len(searchResults.Hits.Hits))
 	for _, hit := range searchResults.Hits.Hits {
 		results = append(results, QueryResult{
-			Score:   hit.Score,
+			Score:      hit.Score,
+			ObjType:    hit.Source.Type,
+			ObjId:      hit.Source.ID,
+			Highlights: parseHighlights(hit.Highlight),
+		})
+	}
+
+	var facets map[string][]FacetBucket
+	if searchResults.Aggregations != nil {
+		facets = parseFacets(searchResults.Aggregations)
+	}
+
+	return &SearchResponse{Hits: results, Facets: facets}, nil
+}
+
+// Suggest matches the prefix against the edge-ngram subfields of song
+// titles and artist names. It only reads the fields it returns so it is
+// cheap enough to call on every keystroke.
+func (oa *OpenSearchAdapter) Suggest(prefix string, limit uint) ([]Suggestion, error) {
+	searchBody := map[string]interface{}{
+		"size":             limit,
+		"track_total_hits": false,
+		"_source":          []string{"id", "type", "title", "name"},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               suggestClauses(prefix),
+				"minimum_should_match": 1,
+			},
+		},
+	}
+
+	bodyBytes, err := json.Marshal(searchBody)
+	if err != nil {
+		return nil, err
+	}
+
+	searchRequest := opensearchapi.SearchRequest{
+		Index: []string{oa.indexName},
+		Body:  bytes.NewReader(bodyBytes),
+	}
+
+	response, err := searchRequest.Do(context.Background(), oa.client)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.IsError() {
+		slog.Error("Error suggesting documents", slog.String("response", response.String()))
+		return nil, fmt.Errorf("error searching index %s", oa.indexName)
+	}
+
+	var searchResults struct {
+		Hits struct {
+			Hits []struct {
+				Source struct {
+					ID    uint   `json:"id"`
+					Type  string `json:"type"`
+					Title string `json:"title"`
+					Name  string `json:"name"`
+				} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&searchResults); err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]Suggestion, 0, len(searchResults.Hits.Hits))
+	for _, hit := range searchResults.Hits.Hits {
+		text := hit.Source.Title
+		if hit.Source.Type == ArtistType {
+			text = hit.Source.Name
+		}
+		suggestions = append(suggestions, Suggestion{
 			ObjType: hit.Source.Type,
 			ObjId:   hit.Source.ID,
+			Text:    text,
 		})
 	}
 
-	return results, nil
+	return suggestions, nil
 }
 
-func (oa *OpenSearchAdapter) indexDocument(id string, body map[string]interface{}) error {
-	bodyBytes, err := json.Marshal(body)
+func suggestClauses(prefix string) []interface{} {
+	prefixes := []string{prefix}
+	if translit.HasCyrillic(prefix) {
+		prefixes = append(prefixes, translit.ToLatin(prefix))
+	}
+
+	clauses := make([]interface{}, 0, len(prefixes))
+	for _, p := range prefixes {
+		clauses = append(clauses, map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":    p,
+				"fields":   []string{"title.prefix", "name.prefix", "title_latin.prefix", "name_latin.prefix"},
+				"operator": "and",
+			},
+		})
+	}
+	return clauses
+}
+
+func (oa *OpenSearchAdapter) indexDocument(doc Document) error {
+	bodyBytes, err := json.Marshal(doc.Body)
 	if err != nil {
 		return err
 	}
 
 	request := opensearchapi.IndexRequest{
 		Index:      oa.indexName,
-		DocumentID: id,
+		DocumentID: doc.ID,
 		Body:       bytes.NewReader(bodyBytes),
 		Refresh:    "true",
 	}
@@ -152,8 +364,30 @@ func (oa *OpenSearchAdapter) indexDocument(id string, body map[string]interface{
 	defer response.Body.Close()
 
 	if response.IsError() {
-		slog.Error("Error indexing document ID %s: %s", id, response.String())
-		return fmt.Errorf("error indexing document ID %s", id)
+		slog.Error("Error indexing document", slog.String("id", doc.ID), slog.String("response", response.String()))
+		return fmt.Errorf("error indexing document ID %s", doc.ID)
+	}
+
+	return nil
+}
+
+func (oa *OpenSearchAdapter) deleteDocument(id string) error {
+	request := opensearchapi.DeleteRequest{
+		Index:      oa.indexName,
+		DocumentID: id,
+		Refresh:    "true",
+	}
+
+	response, err := request.Do(context.Background(), oa.client)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Deleting a document that was never indexed is not an error.
+	if response.IsError() && response.StatusCode != http.StatusNotFound {
+		slog.Error("Error deleting document", slog.String("id", id), slog.String("response", response.String()))
+		return fmt.Errorf("error deleting document ID %s", id)
 	}
 
 	return nil
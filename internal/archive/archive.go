@@ -0,0 +1,156 @@
+// Package archive reads song collections packed as zip or tar files and
+// streams them into zip files.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Limits of what is read from an archive. Song files are far smaller and
+// songbooks have far fewer files; the limits keep a crafted archive from
+// exhausting memory.
+const (
+	MaxFileSize  = 1 << 20
+	MaxFiles     = 10000
+	MaxTotalSize = 256 << 20
+)
+
+// File is a regular file from an archive.
+type File struct {
+	Path string
+	Data []byte
+	// Err is set instead of Data when the file could not be read, for
+	// example when it is larger than MaxFileSize.
+	Err error
+}
+
+var (
+	ErrUnknownFormat = errors.New("unknown archive format, should be zip, tar or tar.gz")
+	ErrTooManyFiles  = fmt.Errorf("archive has more than %d files", MaxFiles)
+	ErrTooLarge      = fmt.Errorf("archive files are larger than %d bytes in total", MaxTotalSize)
+)
+
+// Read lists the regular files of a zip, tar or gzipped tar archive in
+// archive order. Archives with more than MaxFiles entries or more than
+// MaxTotalSize bytes of files are rejected.
+func Read(data []byte) ([]File, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")), bytes.HasPrefix(data, []byte("PK\x05\x06")):
+		return readZip(data)
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		return readTar(gz)
+	case len(data) > 262 && string(data[257:262]) == "ustar":
+		return readTar(bytes.NewReader(data))
+	}
+	return nil, ErrUnknownFormat
+}
+
+func readZip(data []byte) ([]File, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reader.File) > MaxFiles {
+		return nil, ErrTooManyFiles
+	}
+
+	files := make([]File, 0, len(reader.File))
+	total := 0
+	for _, entry := range reader.File {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+		file := File{Path: entry.Name}
+		if content, err := entry.Open(); err != nil {
+			file.Err = err
+		} else {
+			file.Data, file.Err = readLimited(content)
+			content.Close()
+		}
+		if total += len(file.Data); total > MaxTotalSize {
+			return nil, ErrTooLarge
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func readTar(r io.Reader) ([]File, error) {
+	reader := tar.NewReader(r)
+
+	files := make([]File, 0)
+	total := 0
+	for entries := 1; ; entries++ {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entries > MaxFiles {
+			return nil, ErrTooManyFiles
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		file := File{Path: header.Name}
+		file.Data, file.Err = readLimited(reader)
+		if total += len(file.Data); total > MaxTotalSize {
+			return nil, ErrTooLarge
+		}
+		files = append(files, file)
+	}
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", MaxFileSize)
+	}
+	return data, nil
+}
+
+// ZipWriter streams files into a zip archive, every file stamped with the
+// modification time, so large archives do not have to fit in memory.
+type ZipWriter struct {
+	writer   *zip.Writer
+	modified time.Time
+}
+
+func NewZipWriter(w io.Writer, modified time.Time) *ZipWriter {
+	return &ZipWriter{zip.NewWriter(w), modified}
+}
+
+// Add writes the file to the archive. Err of the file is ignored.
+func (z *ZipWriter) Add(file File) error {
+	header := &zip.FileHeader{Name: file.Path, Method: zip.Deflate, Modified: z.modified}
+	entry, err := z.writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(file.Data)
+	return err
+}
+
+// Close writes the central directory; the archive is not readable
+// without it.
+func (z *ZipWriter) Close() error {
+	return z.writer.Close()
+}
@@ -0,0 +1,127 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func zipArchive(t *testing.T, files map[string]string, order ...string) []byte {
+	var b bytes.Buffer
+	writer := zip.NewWriter(&b)
+	for _, name := range order {
+		w, err := writer.Create(name)
+		assert.NoError(t, err)
+		w.Write([]byte(files[name]))
+	}
+	assert.NoError(t, writer.Close())
+	return b.Bytes()
+}
+
+func tarArchive(t *testing.T, files map[string]string, order ...string) []byte {
+	var b bytes.Buffer
+	writer := tar.NewWriter(&b)
+	writer.WriteHeader(&tar.Header{Name: "songs/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for _, name := range order {
+		assert.NoError(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name]))}))
+		writer.Write([]byte(files[name]))
+	}
+	assert.NoError(t, writer.Close())
+	return b.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	files := map[string]string{"songs/b.cho": "{title: B}", "songs/a.cho": "{title: A}"}
+	expected := []File{{Path: "songs/b.cho", Data: []byte("{title: B}")}, {Path: "songs/a.cho", Data: []byte("{title: A}")}}
+
+	read, err := Read(zipArchive(t, files, "songs/b.cho", "songs/a.cho"))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, read)
+
+	tarData := tarArchive(t, files, "songs/b.cho", "songs/a.cho")
+	read, err = Read(tarData)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, read, "expected directories to be skipped")
+
+	var gz bytes.Buffer
+	writer := gzip.NewWriter(&gz)
+	writer.Write(tarData)
+	writer.Close()
+	read, err = Read(gz.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, read)
+}
+
+func TestRead_LimitsFileSize(t *testing.T) {
+	files := map[string]string{"big.cho": strings.Repeat("a", MaxFileSize+1), "small.cho": "ok"}
+
+	read, err := Read(zipArchive(t, files, "big.cho", "small.cho"))
+	assert.NoError(t, err)
+	assert.Error(t, read[0].Err)
+	assert.Nil(t, read[0].Data)
+	assert.Equal(t, []byte("ok"), read[1].Data)
+}
+
+func TestRead_LimitsFileCount(t *testing.T) {
+	files := make(map[string]string, MaxFiles+1)
+	order := make([]string, 0, MaxFiles+1)
+	for i := 0; i <= MaxFiles; i++ {
+		name := fmt.Sprintf("songs/%d.cho", i)
+		files[name] = ""
+		order = append(order, name)
+	}
+
+	_, err := Read(zipArchive(t, files, order...))
+	assert.Equal(t, ErrTooManyFiles, err)
+	_, err = Read(tarArchive(t, files, order[1:]...))
+	assert.Equal(t, ErrTooManyFiles, err, "expected directories to count")
+}
+
+func TestRead_LimitsTotalSize(t *testing.T) {
+	content := strings.Repeat("a", MaxFileSize)
+	files := make(map[string]string)
+	order := make([]string, 0)
+	for i := 0; i <= MaxTotalSize/MaxFileSize; i++ {
+		name := fmt.Sprintf("songs/%d.cho", i)
+		files[name] = content
+		order = append(order, name)
+	}
+
+	_, err := Read(zipArchive(t, files, order...))
+	assert.Equal(t, ErrTooLarge, err)
+}
+
+func TestRead_UnknownFormat(t *testing.T) {
+	_, err := Read([]byte("{title: not an archive}"))
+	assert.Equal(t, ErrUnknownFormat, err)
+}
+
+func writeZip(t *testing.T, files []File, modified time.Time) []byte {
+	var buf bytes.Buffer
+	writer := NewZipWriter(&buf, modified)
+	for _, file := range files {
+		assert.NoError(t, writer.Add(file))
+	}
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestZipWriter(t *testing.T) {
+	files := []File{{Path: "oasis/wonderwall.cho", Data: []byte("{title: Wonderwall}")}, {Path: "manifest.json", Data: []byte("{}")}}
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	data := writeZip(t, files, modified)
+	read, err := Read(data)
+	assert.NoError(t, err)
+	assert.Equal(t, files, read)
+
+	again := writeZip(t, files, modified)
+	assert.Equal(t, data, again, "expected the same files to give the same archive")
+}
@@ -0,0 +1,88 @@
+package chords
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MaxCapo is the highest fret a capo can be put on.
+const MaxCapo = 12
+
+// Capo returns the fret from the {capo} directive. ok is false when there
+// is no valid capo directive.
+func (s *Sheet) Capo() (fret uint, ok bool) {
+	value := strings.TrimSpace(s.DirectiveValue("capo"))
+	if value == "" {
+		return 0, false
+	}
+	capo, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || capo > MaxCapo {
+		return 0, false
+	}
+	return uint(capo), true
+}
+
+// ApplyCapo rewrites chords written as shapes for a capo on fromFret into
+// shapes for a capo on toFret, keeping the sounding pitch. The {capo}
+// directive is updated to the new fret, or removed when toFret is zero.
+func (s *Sheet) ApplyCapo(fromFret, toFret uint) {
+	s.Transpose(int(fromFret) - int(toFret))
+
+	if toFret == 0 {
+		s.RemoveDirective("capo")
+	} else {
+		s.SetDirective("capo", strconv.FormatUint(uint64(toFret), 10))
+	}
+}
+
+// SetDirective sets the value of every directive with the name, adding
+// one at the top of the sheet when there is none.
+func (s *Sheet) SetDirective(name, value string) {
+	found := false
+	for i := range s.Sections {
+		for j := range s.Sections[i].Lines {
+			line := &s.Sections[i].Lines[j]
+			if line.Kind == DirectiveLine && line.Directive.Name == name {
+				line.Directive.Value = value
+				found = true
+			}
+		}
+	}
+	if found {
+		return
+	}
+
+	line := Line{Kind: DirectiveLine, Directive: Directive{name, value}}
+	if len(s.Sections) == 0 || s.Sections[0].Kind != SectionNone {
+		s.Sections = append([]Section{{Kind: SectionNone}}, s.Sections...)
+	}
+	s.Sections[0].Lines = append([]Line{line}, s.Sections[0].Lines...)
+}
+
+// RemoveDirective removes every directive with the name.
+func (s *Sheet) RemoveDirective(name string) {
+	for i := range s.Sections {
+		lines := s.Sections[i].Lines[:0]
+		for _, line := range s.Sections[i].Lines {
+			if line.Kind == DirectiveLine && line.Directive.Name == name {
+				continue
+			}
+			lines = append(lines, line)
+		}
+		s.Sections[i].Lines = lines
+	}
+}
+
+// Clone returns a deep copy of the sheet.
+func (s *Sheet) Clone() *Sheet {
+	clone := &Sheet{Sections: make([]Section, len(s.Sections))}
+	for i, section := range s.Sections {
+		section.Lines = append([]Line(nil), section.Lines...)
+		for j := range section.Lines {
+			section.Lines[j].Chords = append([]ChordPosition(nil), section.Lines[j].Chords...)
+		}
+		section.Staves = append([]TabStaff(nil), section.Staves...)
+		clone.Sections[i] = section
+	}
+	return clone
+}
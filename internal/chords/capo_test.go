@@ -0,0 +1,49 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSheetApplyCapo(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		from, to uint
+		expected string
+	}{
+		{"remove capo", "{capo: 2}\n[Em7]Today is [G]gonna", 2, 0, "[F#m7]Today is [A]gonna"},
+		{"add capo", "[F]Hello [Bb]dark[C]ness", 0, 3, "{capo: 3}\n[D]Hello [G]dark[A]ness"},
+		{"move capo", "{title: Kukushka}\n{capo: 1}\n[Am]Pesen", 1, 5, "{title: Kukushka}\n{capo: 5}\n[Fm]Pesen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet, err := ParseChordPro(tt.content)
+			assert.NoError(t, err)
+			sheet.ApplyCapo(tt.from, tt.to)
+			assert.Equal(t, tt.expected, sheet.String())
+		})
+	}
+}
+
+func TestSheetCapo(t *testing.T) {
+	sheet, _ := ParseChordPro("{capo: 3}\n[G]one")
+	capo, ok := sheet.Capo()
+	assert.True(t, ok)
+	assert.Equal(t, uint(3), capo)
+
+	sheet, _ = ParseChordPro("{capo: 3rd fret}\n[G]one")
+	_, ok = sheet.Capo()
+	assert.False(t, ok)
+}
+
+func TestSheetClone(t *testing.T) {
+	sheet, _ := ParseChordPro("[G]one [C]two")
+	clone := sheet.Clone()
+	clone.Transpose(2)
+
+	assert.Equal(t, "[G]one [C]two", sheet.String())
+	assert.Equal(t, "[A]one [D]two", clone.String())
+}
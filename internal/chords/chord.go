@@ -0,0 +1,278 @@
+package chords
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Quality is the triad a chord is built on.
+type Quality int
+
+const (
+	Major Quality = iota
+	Minor
+	Diminished
+	HalfDiminished
+	Augmented
+	Suspended
+	Power
+)
+
+var sharpNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+var flatNames = [12]string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+var naturalPitches = map[byte]int{
+	'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11,
+	// H is B in German and Russian chord sheets.
+	'H': 11,
+}
+
+var (
+	symbolPattern = regexp.MustCompile(`^([A-H])([#b♯♭]?)([^/]*)(?:/([A-H])([#b♯♭]?))?$`)
+	suffixPattern = regexp.MustCompile(`^(?:maj|min|mi|ma|dim|aug|sus|add|no|alt|[mMΔø°+\-#b♯♭()0-9,])*$`)
+)
+
+// Chord is a parsed chord symbol such as "F#m7b5" or "Cmaj7/G". The
+// suffix is kept verbatim so chords can be respelled without losing
+// extensions.
+type Chord struct {
+	Root    int
+	Suffix  string
+	HasBass bool
+	Bass    int
+	// Flat records whether the symbol spelled its root with a flat, so
+	// String reproduces the original spelling.
+	Flat bool
+}
+
+// ParseChord parses a chord symbol. Roots are A-G (or H), optionally
+// followed by # or b, then a suffix and an optional slash bass note.
+func ParseChord(symbol string) (Chord, error) {
+	symbol = strings.TrimSpace(symbol)
+	match := symbolPattern.FindStringSubmatch(symbol)
+	if match == nil || !suffixPattern.MatchString(match[3]) {
+		return Chord{}, fmt.Errorf("invalid chord %q", symbol)
+	}
+
+	chord := Chord{
+		Root:   pitchClass(match[1][0], match[2]),
+		Suffix: match[3],
+		Flat:   match[2] == "b" || match[2] == "♭",
+	}
+	if match[4] != "" {
+		chord.HasBass = true
+		chord.Bass = pitchClass(match[4][0], match[5])
+	}
+	return chord, nil
+}
+
+// IsChord reports whether symbol parses as a chord.
+func IsChord(symbol string) bool {
+	_, err := ParseChord(symbol)
+	return err == nil
+}
+
+func pitchClass(letter byte, accidental string) int {
+	pitch := naturalPitches[letter]
+	switch accidental {
+	case "#", "♯":
+		pitch++
+	case "b", "♭":
+		pitch--
+	}
+	return mod12(pitch)
+}
+
+// NoteName spells a pitch class with sharps or flats.
+func NoteName(pitch int, flats bool) string {
+	if flats {
+		return flatNames[mod12(pitch)]
+	}
+	return sharpNames[mod12(pitch)]
+}
+
+func (c Chord) String() string {
+	return c.Spell(c.Flat)
+}
+
+// Spell renders the chord with every note spelled using sharps or flats.
+func (c Chord) Spell(flats bool) string {
+	symbol := NoteName(c.Root, flats) + c.Suffix
+	if c.HasBass {
+		symbol += "/" + NoteName(c.Bass, flats)
+	}
+	return symbol
+}
+
+// Quality classifies the triad the suffix describes.
+func (c Chord) Quality() Quality {
+	s := c.Suffix
+	switch {
+	case s == "5":
+		return Power
+	case strings.HasPrefix(s, "ø") || strings.Contains(s, "m7b5") || strings.Contains(s, "m7♭5"):
+		return HalfDiminished
+	case strings.HasPrefix(s, "dim") || strings.HasPrefix(s, "°"):
+		return Diminished
+	case strings.HasPrefix(s, "aug") || strings.HasPrefix(s, "+"):
+		return Augmented
+	case isMinorSuffix(s):
+		return Minor
+	case strings.Contains(s, "sus"):
+		return Suspended
+	default:
+		return Major
+	}
+}
+
+func isMinorSuffix(s string) bool {
+	if strings.HasPrefix(s, "ma") && !strings.HasPrefix(s, "madd") {
+		return false
+	}
+	return strings.HasPrefix(s, "m") || strings.HasPrefix(s, "-")
+}
+
+// Intervals returns the chord tones as sorted semitone offsets from the
+// root, always including the root itself.
+func (c Chord) Intervals() []int {
+	tones := map[int]bool{0: true}
+	s := c.Suffix
+
+	third, fifth, seventh := 4, 7, -1
+	switch c.Quality() {
+	case Power:
+		third = -1
+	case Minor:
+		third = 3
+	case Diminished:
+		third, fifth = 3, 6
+	case HalfDiminished:
+		third, fifth, seventh = 3, 6, 10
+	case Augmented:
+		fifth = 8
+	}
+
+	majorSeventh := strings.Contains(s, "maj7") || strings.Contains(s, "M7") || strings.Contains(s, "Δ") ||
+		strings.Contains(s, "maj9") || strings.Contains(s, "maj11") || strings.Contains(s, "maj13") ||
+		strings.Contains(s, "ma7")
+
+	// Strip alterations and added tones first so the remaining numbers
+	// describe the chord's extension.
+	rest := s
+	for _, alteration := range []struct {
+		token    string
+		interval int
+	}{
+		{"b5", 6}, {"♭5", 6}, {"#5", 8}, {"♯5", 8},
+		{"b9", 1}, {"♭9", 1}, {"#9", 3}, {"♯9", 3},
+		{"#11", 6}, {"♯11", 6}, {"b13", 8}, {"♭13", 8},
+	} {
+		if strings.Contains(rest, alteration.token) {
+			rest = strings.ReplaceAll(rest, alteration.token, "")
+			switch alteration.token {
+			case "b5", "♭5":
+				fifth = 6
+			case "#5", "♯5":
+				fifth = 8
+			default:
+				tones[alteration.interval] = true
+			}
+		}
+	}
+	for _, added := range []struct {
+		token    string
+		interval int
+	}{
+		{"add13", 9}, {"add11", 5}, {"add9", 2}, {"add4", 5}, {"add2", 2},
+		{"sus2", 2}, {"sus4", 5}, {"sus", 5},
+	} {
+		if strings.Contains(rest, added.token) {
+			rest = strings.ReplaceAll(rest, added.token, "")
+			tones[added.interval] = true
+			if strings.HasPrefix(added.token, "sus") {
+				third = -1
+			}
+		}
+	}
+	if strings.Contains(rest, "no3") {
+		third = -1
+	}
+	if strings.Contains(rest, "no5") {
+		fifth = -1
+	}
+
+	switch {
+	case strings.Contains(rest, "69"):
+		tones[9], tones[2] = true, true
+	case strings.Contains(rest, "13"):
+		seventh = dominantOr(seventh, majorSeventh)
+		tones[2], tones[9] = true, true
+	case strings.Contains(rest, "11"):
+		seventh = dominantOr(seventh, majorSeventh)
+		tones[2], tones[5] = true, true
+	case strings.Contains(rest, "9"):
+		seventh = dominantOr(seventh, majorSeventh)
+		tones[2] = true
+	case strings.Contains(rest, "7"):
+		switch {
+		case c.Quality() == Diminished:
+			seventh = 9
+		case c.Quality() == HalfDiminished:
+			seventh = 10
+		default:
+			seventh = dominantOr(seventh, majorSeventh)
+		}
+	case strings.Contains(rest, "6"):
+		tones[9] = true
+	}
+
+	for _, tone := range []int{third, fifth, seventh} {
+		if tone >= 0 {
+			tones[tone] = true
+		}
+	}
+
+	intervals := make([]int, 0, len(tones))
+	for tone := range tones {
+		intervals = append(intervals, tone)
+	}
+	sort.Ints(intervals)
+	return intervals
+}
+
+func dominantOr(seventh int, majorSeventh bool) int {
+	if seventh >= 0 {
+		return seventh
+	}
+	if majorSeventh {
+		return 11
+	}
+	return 10
+}
+
+// PitchClasses returns the pitch classes sounding in the chord, including
+// the bass note of a slash chord.
+func (c Chord) PitchClasses() []int {
+	pitches := make([]int, 0, 5)
+	for _, interval := range c.Intervals() {
+		pitches = append(pitches, mod12(c.Root+interval))
+	}
+	if c.HasBass {
+		found := false
+		for _, pitch := range pitches {
+			if pitch == c.Bass {
+				found = true
+			}
+		}
+		if !found {
+			pitches = append(pitches, c.Bass)
+		}
+	}
+	return pitches
+}
+
+func mod12(n int) int {
+	return ((n % 12) + 12) % 12
+}
@@ -0,0 +1,90 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChord(t *testing.T) {
+	tests := []struct {
+		symbol  string
+		root    int
+		suffix  string
+		hasBass bool
+		bass    int
+	}{
+		{"C", 0, "", false, 0},
+		{"Am", 9, "m", false, 0},
+		{"F#m7b5", 6, "m7b5", false, 0},
+		{"Bb", 10, "", false, 0},
+		{"Cmaj7/G", 0, "maj7", true, 7},
+		{"D/F#", 2, "", true, 6},
+		{"Hm", 11, "m", false, 0},
+		{"E7(b9)", 4, "7(b9)", false, 0},
+		{"Gsus4", 7, "sus4", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			chord, err := ParseChord(tt.symbol)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.root, chord.Root)
+			assert.Equal(t, tt.suffix, chord.Suffix)
+			assert.Equal(t, tt.hasBass, chord.HasBass)
+			assert.Equal(t, tt.bass, chord.Bass)
+		})
+	}
+}
+
+func TestParseChord_Invalid(t *testing.T) {
+	for _, symbol := range []string{"", "Chorus", "Verse", "X", "am", "C/X", "Bad"} {
+		assert.False(t, IsChord(symbol), "expected %q not to be a chord", symbol)
+	}
+}
+
+func TestChordString_KeepsSpelling(t *testing.T) {
+	for _, symbol := range []string{"Bbmaj7", "C#m", "Ebm7/Db", "G"} {
+		chord, _ := ParseChord(symbol)
+		assert.Equal(t, symbol, chord.String())
+	}
+}
+
+func TestChordIntervals(t *testing.T) {
+	tests := []struct {
+		symbol    string
+		intervals []int
+		quality   Quality
+	}{
+		{"C", []int{0, 4, 7}, Major},
+		{"Cm", []int{0, 3, 7}, Minor},
+		{"C5", []int{0, 7}, Power},
+		{"Cdim", []int{0, 3, 6}, Diminished},
+		{"Cdim7", []int{0, 3, 6, 9}, Diminished},
+		{"Cm7b5", []int{0, 3, 6, 10}, HalfDiminished},
+		{"Caug", []int{0, 4, 8}, Augmented},
+		{"C7", []int{0, 4, 7, 10}, Major},
+		{"Cmaj7", []int{0, 4, 7, 11}, Major},
+		{"Cm7", []int{0, 3, 7, 10}, Minor},
+		{"CmM7", []int{0, 3, 7, 11}, Minor},
+		{"Csus4", []int{0, 5, 7}, Suspended},
+		{"C7sus4", []int{0, 5, 7, 10}, Suspended},
+		{"Csus2", []int{0, 2, 7}, Suspended},
+		{"Cadd9", []int{0, 2, 4, 7}, Major},
+		{"Cmadd9", []int{0, 2, 3, 7}, Minor},
+		{"C6", []int{0, 4, 7, 9}, Major},
+		{"C9", []int{0, 2, 4, 7, 10}, Major},
+		{"Cmaj9", []int{0, 2, 4, 7, 11}, Major},
+		{"C7b9", []int{0, 1, 4, 7, 10}, Major},
+		{"C7#5", []int{0, 4, 8, 10}, Major},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			chord, err := ParseChord(tt.symbol)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.intervals, chord.Intervals())
+			assert.Equal(t, tt.quality, chord.Quality())
+		})
+	}
+}
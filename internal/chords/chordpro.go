@@ -0,0 +1,476 @@
+package chords
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SectionKind is the ChordPro environment a section was written in.
+type SectionKind string
+
+const (
+	// SectionNone holds lines outside of any environment.
+	SectionNone   SectionKind = ""
+	SectionVerse  SectionKind = "verse"
+	SectionChorus SectionKind = "chorus"
+	SectionBridge SectionKind = "bridge"
+	SectionGrid   SectionKind = "grid"
+	// SectionTab holds ASCII tablature, its lines are kept as written.
+	SectionTab SectionKind = "tab"
+)
+
+type LineKind int
+
+const (
+	LyricsLine LineKind = iota
+	DirectiveLine
+	// CommentLine is a source comment starting with #, not a {comment}.
+	CommentLine
+	EmptyLine
+	// TabLine is a line of a tab section.
+	TabLine
+)
+
+// Sheet is a parsed ChordPro song.
+type Sheet struct {
+	Sections []Section
+}
+
+// Section is a run of lines in one environment. Label is the optional
+// argument of the start directive, as in {start_of_chorus: Chorus 2}.
+// Staves are the parsed tab lines of a tab section.
+type Section struct {
+	Kind   SectionKind
+	Label  string
+	Lines  []Line
+	Staves []TabStaff
+}
+
+// Line is a single source line. Text is the lyrics of a lyrics line with
+// the chords taken out, the text after # of a comment line, or a tab line
+// as written.
+type Line struct {
+	Kind      LineKind
+	Number    int
+	Text      string
+	Chords    []ChordPosition
+	Directive Directive
+}
+
+// ChordPosition is a chord placed before the rune at Offset in the line
+// text. Annotations ([*Riff]) and no-chord markers ([N.C.]) have no Chord.
+type ChordPosition struct {
+	Offset     int
+	Symbol     string
+	Chord      Chord
+	Annotation bool
+}
+
+// Directive is a {name: value} directive with its name in canonical long
+// form.
+type Directive struct {
+	Name  string
+	Value string
+}
+
+// ParseError is a content error at a 1-based line and column.
+type ParseError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ParseErrors are all the errors found in a song.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+var directiveAliases = map[string]string{
+	"t": "title", "st": "subtitle", "c": "comment", "ci": "comment_italic", "cb": "comment_box",
+	"soc": "start_of_chorus", "eoc": "end_of_chorus",
+	"sov": "start_of_verse", "eov": "end_of_verse",
+	"sob": "start_of_bridge", "eob": "end_of_bridge",
+	"sog": "start_of_grid", "eog": "end_of_grid",
+	"sot": "start_of_tab", "eot": "end_of_tab",
+	"ns": "new_song", "np": "new_page", "npp": "new_physical_page",
+	"col": "columns", "colb": "column_break",
+}
+
+// knownDirectives are the ChordPro directives besides environments.
+// Custom directives prefixed with x_ are always accepted, instrument and
+// tuning are ours to keep a song's tuning in its file.
+var knownDirectives = map[string]bool{
+	"title": true, "subtitle": true, "artist": true, "composer": true, "lyricist": true,
+	"copyright": true, "album": true, "year": true, "key": true, "time": true,
+	"tempo": true, "duration": true, "capo": true, "meta": true,
+	"instrument": true, "tuning": true,
+	"comment": true, "comment_italic": true, "comment_box": true, "highlight": true,
+	"chorus": true, "image": true, "define": true, "chord": true,
+	"new_song": true, "new_page": true, "new_physical_page": true,
+	"columns": true, "column_break": true, "pagetype": true,
+	"textfont": true, "textsize": true, "textcolour": true,
+	"chordfont": true, "chordsize": true, "chordcolour": true,
+}
+
+var environments = map[SectionKind]bool{
+	SectionVerse: true, SectionChorus: true, SectionBridge: true, SectionGrid: true, SectionTab: true,
+}
+
+// ParseChordPro parses ChordPro content. It reports every problem it finds
+// as ParseErrors in line and column order rather than stopping at the
+// first one.
+func ParseChordPro(content string) (*Sheet, error) {
+	p := chordProParser{sheet: &Sheet{}}
+
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, source := range lines {
+		p.parseLine(i+1, source)
+	}
+
+	if p.open != nil {
+		p.errorf(p.openLine, 1, "start_of_%s is not closed", p.open.Kind)
+	}
+
+	if len(p.errors) > 0 {
+		// Unclosed sections are only found at the end.
+		sort.SliceStable(p.errors, func(i, j int) bool {
+			if p.errors[i].Line != p.errors[j].Line {
+				return p.errors[i].Line < p.errors[j].Line
+			}
+			return p.errors[i].Column < p.errors[j].Column
+		})
+		return nil, p.errors
+	}
+	return p.sheet, nil
+}
+
+type chordProParser struct {
+	sheet    *Sheet
+	open     *Section
+	openLine int
+	errors   ParseErrors
+}
+
+func (p *chordProParser) errorf(line, column int, format string, args ...interface{}) {
+	p.errors = append(p.errors, &ParseError{line, column, fmt.Sprintf(format, args...)})
+}
+
+// current returns the section new lines go to, starting an implicit one
+// after an environment closes.
+func (p *chordProParser) current() *Section {
+	if p.open != nil {
+		return p.open
+	}
+	sections := p.sheet.Sections
+	if len(sections) == 0 || sections[len(sections)-1].Kind != SectionNone {
+		p.sheet.Sections = append(p.sheet.Sections, Section{Kind: SectionNone})
+	}
+	return &p.sheet.Sections[len(p.sheet.Sections)-1]
+}
+
+func (p *chordProParser) addLine(line Line) {
+	section := p.current()
+	section.Lines = append(section.Lines, line)
+}
+
+func (p *chordProParser) parseLine(number int, source string) {
+	trimmed := strings.TrimSpace(source)
+	switch {
+	case trimmed == "":
+		p.addLine(Line{Kind: EmptyLine, Number: number})
+	case strings.HasPrefix(trimmed, "{"):
+		p.parseDirective(number, source)
+	case p.open != nil && p.open.Kind == SectionTab:
+		p.addLine(Line{Kind: TabLine, Number: number, Text: strings.TrimRight(source, " \t")})
+	case strings.HasPrefix(trimmed, "#"):
+		p.addLine(Line{Kind: CommentLine, Number: number, Text: strings.TrimPrefix(trimmed, "#")})
+	default:
+		p.parseLyrics(number, source)
+	}
+}
+
+func (p *chordProParser) parseDirective(number int, source string) {
+	runes := []rune(source)
+	start := 0
+	for runes[start] != '{' {
+		start++
+	}
+	end := start
+	for end < len(runes) && runes[end] != '}' {
+		end++
+	}
+	if end == len(runes) {
+		p.errorf(number, start+1, "unclosed directive")
+		return
+	}
+	if rest := string(runes[end+1:]); strings.TrimSpace(rest) != "" {
+		leading := len([]rune(rest)) - len([]rune(strings.TrimLeft(rest, " \t")))
+		p.errorf(number, end+2+leading, "unexpected text after directive")
+		return
+	}
+
+	body := string(runes[start+1 : end])
+	name, value, _ := strings.Cut(body, ":")
+	name = strings.ToLower(strings.TrimSpace(name))
+	value = strings.TrimSpace(value)
+	if alias, ok := directiveAliases[name]; ok {
+		name = alias
+	}
+	column := start + 2
+
+	switch {
+	case name == "":
+		p.errorf(number, column, "empty directive")
+	case strings.HasPrefix(name, "start_of_"):
+		p.startSection(number, column, SectionKind(strings.TrimPrefix(name, "start_of_")), value)
+	case strings.HasPrefix(name, "end_of_"):
+		p.endSection(number, column, SectionKind(strings.TrimPrefix(name, "end_of_")))
+	case knownDirectives[name] || strings.HasPrefix(name, "x_"):
+		p.addLine(Line{Kind: DirectiveLine, Number: number, Directive: Directive{name, value}})
+	default:
+		p.errorf(number, column, "unknown directive %q", name)
+	}
+}
+
+func (p *chordProParser) startSection(number, column int, kind SectionKind, label string) {
+	if !environments[kind] {
+		p.errorf(number, column, "unknown directive %q", "start_of_"+string(kind))
+		return
+	}
+	if p.open != nil {
+		p.errorf(number, column, "start_of_%s inside %s", kind, p.open.Kind)
+		return
+	}
+	p.sheet.Sections = append(p.sheet.Sections, Section{Kind: kind, Label: label})
+	p.open = &p.sheet.Sections[len(p.sheet.Sections)-1]
+	p.openLine = number
+}
+
+func (p *chordProParser) endSection(number, column int, kind SectionKind) {
+	if !environments[kind] {
+		p.errorf(number, column, "unknown directive %q", "end_of_"+string(kind))
+		return
+	}
+	if p.open == nil || p.open.Kind != kind {
+		p.errorf(number, column, "end_of_%s without start_of_%s", kind, kind)
+		return
+	}
+	if kind == SectionTab {
+		p.parseTab(p.open)
+	}
+	p.open = nil
+}
+
+func (p *chordProParser) parseTab(section *Section) {
+	section.Staves = parseTabStaves(section.Lines)
+	for _, staff := range section.Staves {
+		for _, tabString := range staff.Strings {
+			for _, note := range tabString.Notes {
+				if note.Fret > MaxTabFret {
+					p.errorf(section.Lines[tabString.Line].Number, note.Position+1, "fret %d is above %d", note.Fret, MaxTabFret)
+				}
+			}
+		}
+	}
+}
+
+func (p *chordProParser) parseLyrics(number int, source string) {
+	line := Line{Kind: LyricsLine, Number: number, Chords: make([]ChordPosition, 0)}
+
+	var text []rune
+	runes := []rune(strings.TrimRight(source, " \t"))
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' && runes[end] != '[' {
+				end++
+			}
+			if end == len(runes) || runes[end] == '[' {
+				p.errorf(number, i+1, "unclosed chord bracket")
+				return
+			}
+			position, ok := p.parseChordPosition(number, i+2, string(runes[i+1:end]))
+			if ok {
+				position.Offset = len(text)
+				line.Chords = append(line.Chords, position)
+			}
+			i = end
+		case ']':
+			p.errorf(number, i+1, "unexpected ]")
+			return
+		default:
+			text = append(text, runes[i])
+		}
+	}
+
+	line.Text = string(text)
+	p.addLine(line)
+}
+
+func (p *chordProParser) parseChordPosition(number, column int, symbol string) (ChordPosition, bool) {
+	switch {
+	case strings.TrimSpace(symbol) == "":
+		p.errorf(number, column-1, "empty chord")
+		return ChordPosition{}, false
+	case strings.HasPrefix(symbol, "*"), isNoChord(symbol):
+		return ChordPosition{Symbol: symbol, Annotation: true}, true
+	}
+
+	chord, err := ParseChord(symbol)
+	if err != nil {
+		p.errorf(number, column, "invalid chord %q", symbol)
+		return ChordPosition{}, false
+	}
+	return ChordPosition{Symbol: symbol, Chord: chord}, true
+}
+
+func isNoChord(symbol string) bool {
+	switch strings.ToUpper(strings.TrimSpace(symbol)) {
+	case "N.C.", "N.C", "NC":
+		return true
+	}
+	return false
+}
+
+// Title returns the value of the first title directive.
+func (s *Sheet) Title() string {
+	return s.DirectiveValue("title")
+}
+
+// DirectiveValue returns the value of the first directive with the name.
+func (s *Sheet) DirectiveValue(name string) string {
+	for _, section := range s.Sections {
+		for _, line := range section.Lines {
+			if line.Kind == DirectiveLine && line.Directive.Name == name {
+				return line.Directive.Value
+			}
+		}
+	}
+	return ""
+}
+
+// DirectiveValues returns the values of every directive with the name in
+// order, as songs can have several {artist} directives.
+func (s *Sheet) DirectiveValues(name string) []string {
+	values := make([]string, 0)
+	for _, section := range s.Sections {
+		for _, line := range section.Lines {
+			if line.Kind == DirectiveLine && line.Directive.Name == name {
+				values = append(values, line.Directive.Value)
+			}
+		}
+	}
+	return values
+}
+
+// Chords returns the chords of the sheet in order, without annotations.
+func (s *Sheet) Chords() []Chord {
+	sequence := make([]Chord, 0)
+	for _, section := range s.Sections {
+		for _, line := range section.Lines {
+			for _, position := range line.Chords {
+				if !position.Annotation {
+					sequence = append(sequence, position.Chord)
+				}
+			}
+		}
+	}
+	return sequence
+}
+
+// String serializes the sheet back to ChordPro, writing directives in
+// their long form.
+func (s *Sheet) String() string {
+	lines := make([]string, 0)
+	for _, section := range s.Sections {
+		if section.Kind != SectionNone {
+			lines = append(lines, Directive{"start_of_" + string(section.Kind), section.Label}.String())
+		}
+		for _, line := range section.Lines {
+			lines = append(lines, line.String())
+		}
+		if section.Kind != SectionNone {
+			lines = append(lines, Directive{Name: "end_of_" + string(section.Kind)}.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (d Directive) String() string {
+	if d.Value == "" {
+		return "{" + d.Name + "}"
+	}
+	return "{" + d.Name + ": " + d.Value + "}"
+}
+
+func (l Line) String() string {
+	switch l.Kind {
+	case DirectiveLine:
+		return l.Directive.String()
+	case CommentLine:
+		return "#" + l.Text
+	case EmptyLine:
+		return ""
+	case TabLine:
+		return l.Text
+	}
+
+	var builder strings.Builder
+	text := []rune(l.Text)
+	next := 0
+	for i := 0; i <= len(text); i++ {
+		for next < len(l.Chords) && l.Chords[next].Offset == i {
+			builder.WriteString("[" + l.Chords[next].Symbol + "]")
+			next++
+		}
+		if i < len(text) {
+			builder.WriteRune(text[i])
+		}
+	}
+	return builder.String()
+}
+
+// ChordsOverLyrics lays a lyrics line out as a line of chords above a line
+// of lyrics. Lyrics are padded with spaces where chords would otherwise
+// run into each other. Annotations are shown without their leading *.
+func (l Line) ChordsOverLyrics() (chordLine, lyricLine string) {
+	if len(l.Chords) == 0 {
+		return "", l.Text
+	}
+
+	text := []rune(l.Text)
+	var chordsBuilder, lyricsBuilder strings.Builder
+	chordsBuilder.WriteString(strings.Repeat(" ", l.Chords[0].Offset))
+	lyricsBuilder.WriteString(string(text[:l.Chords[0].Offset]))
+
+	for i, position := range l.Chords {
+		end := len(text)
+		if i+1 < len(l.Chords) {
+			end = l.Chords[i+1].Offset
+		}
+		segment := text[position.Offset:end]
+		symbol := []rune(strings.TrimPrefix(position.Symbol, "*"))
+
+		width := len(segment)
+		if i+1 < len(l.Chords) && width < len(symbol)+1 {
+			width = len(symbol) + 1
+		}
+		chordsBuilder.WriteString(string(symbol))
+		chordsBuilder.WriteString(strings.Repeat(" ", max(width-len(symbol), 0)))
+		lyricsBuilder.WriteString(string(segment))
+		lyricsBuilder.WriteString(strings.Repeat(" ", width-len(segment)))
+	}
+	return strings.TrimRight(chordsBuilder.String(), " "), strings.TrimRight(lyricsBuilder.String(), " ")
+}
@@ -0,0 +1,135 @@
+package chords
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const wonderwall = `{title: Wonderwall}
+{artist: Oasis}
+# capo on the 2nd fret
+[Em7]Today is [G]gonna be the day
+
+{start_of_chorus: Chorus}
+And [C]all the roads we [D]have to walk are [Em]winding[*!]
+{end_of_chorus}
+{comment: Outro}
+[N.C.]I said maybe[C]`
+
+func TestParseChordPro(t *testing.T) {
+	sheet, err := ParseChordPro(wonderwall)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Wonderwall", sheet.Title())
+	assert.Equal(t, "Oasis", sheet.DirectiveValue("artist"))
+	assert.Equal(t, []string{"Oasis"}, sheet.DirectiveValues("artist"))
+	assert.Empty(t, sheet.DirectiveValues("album"))
+	assert.Len(t, sheet.Sections, 3)
+
+	intro := sheet.Sections[0]
+	assert.Equal(t, SectionNone, intro.Kind)
+	assert.Equal(t, []LineKind{DirectiveLine, DirectiveLine, CommentLine, LyricsLine, EmptyLine}, lineKinds(intro))
+	assert.Equal(t, "Today is gonna be the day", intro.Lines[3].Text)
+	assert.Equal(t, 4, intro.Lines[3].Number)
+	assert.Equal(t, 0, intro.Lines[3].Chords[0].Offset)
+	assert.Equal(t, "G", intro.Lines[3].Chords[1].Symbol)
+	assert.Equal(t, 9, intro.Lines[3].Chords[1].Offset)
+
+	chorus := sheet.Sections[1]
+	assert.Equal(t, SectionChorus, chorus.Kind)
+	assert.Equal(t, "Chorus", chorus.Label)
+	assert.Len(t, chorus.Lines, 1)
+	assert.True(t, chorus.Lines[0].Chords[3].Annotation)
+
+	outro := sheet.Sections[2]
+	assert.Equal(t, Directive{"comment", "Outro"}, outro.Lines[0].Directive)
+	assert.True(t, outro.Lines[1].Chords[0].Annotation, "expected N.C. to be an annotation")
+	assert.Equal(t, len([]rune("I said maybe")), outro.Lines[1].Chords[1].Offset)
+
+	symbols := make([]string, 0)
+	for _, chord := range sheet.Chords() {
+		symbols = append(symbols, chord.String())
+	}
+	assert.Equal(t, []string{"Em7", "G", "C", "D", "Em", "C"}, symbols)
+}
+
+func TestParseChordPro_RoundTrip(t *testing.T) {
+	sheet, err := ParseChordPro(wonderwall)
+	assert.NoError(t, err)
+	assert.Equal(t, wonderwall, sheet.String())
+
+	sheet, err = ParseChordPro("{t: Кукушка}\n{soc}\n[Am]Песен ещё [C]ненаписанных\n{eoc}")
+	assert.NoError(t, err)
+	assert.Equal(t, "{title: Кукушка}\n{start_of_chorus}\n[Am]Песен ещё [C]ненаписанных\n{end_of_chorus}", sheet.String())
+}
+
+func TestLine_ChordsOverLyrics(t *testing.T) {
+	tests := []struct {
+		content string
+		chords  string
+		lyrics  string
+	}{
+		{"[Em7]Today is [G]gonna be the day", "Em7      G", "Today is gonna be the day"},
+		{"So [Am]I [F]a[C]m", "   Am F C", "So I  a m"},
+		{"[*Riff][G]", "Riff G", ""},
+		{"No chords here", "", "No chords here"},
+		{"Песен [Am]ещё", "      Am", "Песен ещё"},
+	}
+
+	for _, tt := range tests {
+		sheet, err := ParseChordPro(tt.content)
+		assert.NoError(t, err)
+
+		chords, lyrics := sheet.Sections[0].Lines[0].ChordsOverLyrics()
+		assert.Equal(t, tt.chords, chords, tt.content)
+		assert.Equal(t, tt.lyrics, lyrics, tt.content)
+	}
+}
+
+func TestParseChordPro_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errors  []ParseError
+	}{
+		{"unclosed chord", "[Am]Hello [G world", []ParseError{{1, 11, "unclosed chord bracket"}}},
+		{"stray bracket", "Hello] world", []ParseError{{1, 6, "unexpected ]"}}},
+		{"empty chord", "one\nHello []world", []ParseError{{2, 7, "empty chord"}}},
+		{"invalid chord", "[Am]Hello [Xyz]world", []ParseError{{1, 12, `invalid chord "Xyz"`}}},
+		{"unclosed directive", "{title: Wonderwall", []ParseError{{1, 1, "unclosed directive"}}},
+		{"text after directive", "{title: Wonderwall} oops", []ParseError{{1, 21, "unexpected text after directive"}}},
+		{"unknown directive", "  {colour: red}", []ParseError{{1, 4, `unknown directive "colour"`}}},
+		{"unmatched end", "{end_of_chorus}", []ParseError{{1, 2, "end_of_chorus without start_of_chorus"}}},
+		{"nested sections", "{soc}\n{sov}\n{eoc}", []ParseError{{2, 2, "start_of_verse inside chorus"}}},
+		{"unclosed section", "[C]one\n{start_of_verse}\n[G]two", []ParseError{{2, 1, "start_of_verse is not closed"}}},
+		{"several errors", "[Xyz]one\n[Am two", []ParseError{{1, 2, `invalid chord "Xyz"`}, {2, 1, "unclosed chord bracket"}}},
+		{"errors in line order", "{sov}\n[Xyz]one\n[C]two [Am", []ParseError{
+			{1, 1, "start_of_verse is not closed"}, {2, 2, `invalid chord "Xyz"`}, {3, 8, "unclosed chord bracket"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseChordPro(tt.content)
+
+			var parseErrors ParseErrors
+			assert.True(t, errors.As(err, &parseErrors), "expected ParseErrors, got %v", err)
+
+			actual := make([]ParseError, 0, len(parseErrors))
+			for _, parseError := range parseErrors {
+				actual = append(actual, *parseError)
+			}
+			assert.Equal(t, tt.errors, actual)
+		})
+	}
+}
+
+func lineKinds(section Section) []LineKind {
+	kinds := make([]LineKind, 0, len(section.Lines))
+	for _, line := range section.Lines {
+		kinds = append(kinds, line.Kind)
+	}
+	return kinds
+}
@@ -0,0 +1,173 @@
+package chords
+
+// Difficulty is a coarse playing difficulty of a song on guitar.
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "easy"
+	DifficultyMedium Difficulty = "medium"
+	DifficultyHard   Difficulty = "hard"
+)
+
+var Difficulties = []Difficulty{DifficultyEasy, DifficultyMedium, DifficultyHard}
+
+// openShapes are the chords playable in first position without a barre in
+// standard tuning.
+var openShapes = map[string]bool{
+	"C": true, "D": true, "E": true, "G": true, "A": true,
+	"Am": true, "Dm": true, "Em": true,
+	"A7": true, "B7": true, "C7": true, "D7": true, "E7": true, "G7": true,
+	"Am7": true, "Dm7": true, "Em7": true,
+	"Cmaj7": true, "Dmaj7": true, "Emaj7": true, "Fmaj7": true, "Gmaj7": true, "Amaj7": true,
+	"Asus2": true, "Asus4": true, "Dsus2": true, "Dsus4": true, "Esus4": true,
+	"Cadd9": true, "Gadd9": true, "E5": true, "A5": true, "D5": true, "G5": true,
+}
+
+const (
+	openChordCost     = 1
+	slashChordPenalty = 0.5
+	barreChordCost    = 2
+	complexChordCost  = 3
+
+	maxEasyChords     = 8
+	hardScore         = 2.2
+	extraChordPenalty = 0.1
+	// Songs with more distinct chords than this get harder per chord.
+	chordCountAllowance = 6
+)
+
+// ParseDifficulty validates a difficulty name.
+func ParseDifficulty(s string) (Difficulty, bool) {
+	for _, difficulty := range Difficulties {
+		if string(difficulty) == s {
+			return difficulty, true
+		}
+	}
+	return "", false
+}
+
+// IsOpenShape reports whether the chord, ignoring a slash bass, can be
+// played as an open chord in standard tuning.
+func IsOpenShape(chord Chord) bool {
+	return openShapes[shapeName(chord)]
+}
+
+// ChordCost rates how hard a chord is to finger: open chords are the
+// cheapest, then barre triads and sevenths, then extended and altered
+// chords.
+func ChordCost(chord Chord) float64 {
+	cost := float64(barreChordCost)
+	switch {
+	case IsOpenShape(chord):
+		cost = openChordCost
+	case isComplex(chord):
+		cost = complexChordCost
+	}
+	if chord.HasBass && chord.Bass != chord.Root {
+		cost += slashChordPenalty
+	}
+	return cost
+}
+
+// EstimateDifficulty rates a chord sequence by the fingering cost of its
+// distinct chords and how many of them there are. ok is false when there
+// are no chords.
+func EstimateDifficulty(sequence []Chord) (difficulty Difficulty, ok bool) {
+	distinct := distinctChords(sequence)
+	if len(distinct) == 0 {
+		return "", false
+	}
+
+	allOpen := true
+	for _, chord := range distinct {
+		if ChordCost(chord) > openChordCost+slashChordPenalty {
+			allOpen = false
+		}
+	}
+
+	switch {
+	case allOpen && len(distinct) <= maxEasyChords:
+		return DifficultyEasy, true
+	case difficultyScore(distinct) >= hardScore:
+		return DifficultyHard, true
+	default:
+		return DifficultyMedium, true
+	}
+}
+
+// DifficultyScore is the average ChordCost of the distinct chords, raised
+// for songs with many of them. ok is false when there are no chords.
+func DifficultyScore(sequence []Chord) (score float64, ok bool) {
+	distinct := distinctChords(sequence)
+	if len(distinct) == 0 {
+		return 0, false
+	}
+	return difficultyScore(distinct), true
+}
+
+func difficultyScore(distinct map[string]Chord) float64 {
+	var total float64
+	for _, chord := range distinct {
+		total += ChordCost(chord)
+	}
+
+	score := total / float64(len(distinct))
+	if len(distinct) > chordCountAllowance {
+		score += extraChordPenalty * float64(len(distinct)-chordCountAllowance)
+	}
+	return score
+}
+
+func distinctChords(sequence []Chord) map[string]Chord {
+	distinct := make(map[string]Chord)
+	for _, chord := range sequence {
+		distinct[chord.Spell(false)] = chord
+	}
+	return distinct
+}
+
+// shapeName spells the chord with sharps and a canonical triad suffix so
+// it can be looked up among the open shapes.
+func shapeName(chord Chord) string {
+	suffix := chord.Suffix
+	switch suffix {
+	case "min", "mi", "-":
+		suffix = "m"
+	case "maj", "M":
+		suffix = ""
+	case "M7", "ma7", "Δ", "Δ7":
+		suffix = "maj7"
+	case "min7", "mi7", "-7":
+		suffix = "m7"
+	case "sus":
+		suffix = "sus4"
+	}
+	return NoteName(chord.Root, false) + suffix
+}
+
+func isComplex(chord Chord) bool {
+	switch chord.Quality() {
+	case Diminished, HalfDiminished, Augmented:
+		return true
+	}
+	for _, interval := range chord.Intervals() {
+		// Ninths, elevenths, thirteenths and their alterations.
+		switch interval {
+		case 1, 2, 3, 5, 6, 8, 9:
+			if !isTriadTone(chord, interval) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isTriadTone(chord Chord, interval int) bool {
+	switch chord.Quality() {
+	case Minor:
+		return interval == 3
+	case Suspended:
+		return interval == 2 || interval == 5
+	}
+	return false
+}
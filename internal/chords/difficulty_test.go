@@ -0,0 +1,41 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateDifficulty(t *testing.T) {
+	tests := []struct {
+		name       string
+		sequence   []string
+		difficulty Difficulty
+	}{
+		{"open chords", []string{"G", "D", "Em", "C"}, DifficultyEasy},
+		{"open chords with slash bass", []string{"C", "G/B", "Am", "F/C"}, DifficultyMedium},
+		{"one barre chord", []string{"Am", "F", "C", "G"}, DifficultyMedium},
+		{"barre chords", []string{"Bm", "F#m", "A", "D", "E"}, DifficultyMedium},
+		{"jazz", []string{"Cm7b5", "F7b9", "Bbmaj7", "Ebmaj7", "Abmaj7", "Dm7b5", "G7#9", "Cm9"}, DifficultyHard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			difficulty, ok := EstimateDifficulty(parseSequence(t, tt.sequence...))
+			assert.True(t, ok)
+			assert.Equal(t, tt.difficulty, difficulty)
+		})
+	}
+
+	_, ok := EstimateDifficulty(nil)
+	assert.False(t, ok)
+}
+
+func TestIsOpenShape_NormalisesSpelling(t *testing.T) {
+	for _, symbol := range []string{"Amin", "A-", "CM7", "Dsus", "E7/G#"} {
+		chord, _ := ParseChord(symbol)
+		assert.True(t, IsOpenShape(chord), symbol)
+	}
+	chord, _ := ParseChord("Bb")
+	assert.False(t, IsOpenShape(chord))
+}
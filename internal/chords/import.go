@@ -0,0 +1,262 @@
+package chords
+
+import (
+	"regexp"
+	"strings"
+)
+
+// importTabWidth is how many columns a tab advances in pasted sheets.
+const importTabWidth = 8
+
+// sectionHeaderPattern matches header lines such as "[Verse 1]",
+// "Chorus:" or "Припев 2:". The whole label without brackets and colon is
+// the first group, the name the second. The name ends at a space, a digit
+// or the end of the label rather than at \b, which only knows ASCII
+// letters and never matches after a Cyrillic name.
+var sectionHeaderPattern = regexp.MustCompile(`(?i)^\[?\s*((verse|chorus|refrain|pre-?chorus|bridge|intro|outro|interlude|instrumental|solo|coda|hook|tag|куплет|припев|бридж|вступление|проигрыш|кода|соло)(?:[\s\d][^\]:]*?)?)\s*\]?\s*:?$`)
+
+// bracketedHeaderPattern matches any "[Label]" alone on a line, which is a
+// header when the label is not a chord.
+var bracketedHeaderPattern = regexp.MustCompile(`^\[([^\[\]]+)\]$`)
+
+// sectionEnvironments maps header names to the ChordPro environment they
+// open. Other headers become comments.
+var sectionEnvironments = map[string]SectionKind{
+	"verse": SectionVerse, "куплет": SectionVerse,
+	"chorus": SectionChorus, "refrain": SectionChorus, "припев": SectionChorus,
+	"bridge": SectionBridge, "бридж": SectionBridge,
+}
+
+// chordLineExtras are tokens that may appear on a chord line besides
+// chords: bar lines, repeat marks and no-chord markers.
+var chordLineExtras = regexp.MustCompile(`(?i)^(\||\|\||-+|/|%|x\d+|\(x\d+\)|\d+x)$`)
+
+// IsChordsOverLyrics reports whether content is a plain text sheet with
+// chords on their own lines above the lyrics rather than ChordPro: it has
+// a chord line and no directives or inline chords.
+func IsChordsOverLyrics(content string) bool {
+	chordLines := 0
+	for _, line := range importLines(content) {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "{") && strings.HasSuffix(trimmed, "}") {
+			return false
+		}
+		if _, ok := chordLineChords(line); ok {
+			chordLines++
+			continue
+		}
+		for _, match := range inlineChordPattern.FindAllStringSubmatch(line, -1) {
+			if IsChord(match[1]) {
+				return false
+			}
+		}
+	}
+	return chordLines > 0
+}
+
+// ImportChordsOverLyrics converts a chords-over-lyrics sheet to ChordPro.
+// Chords are placed at their column in the lyrics line below; chord lines
+// without lyrics are kept as lines of chords. Verse, chorus and bridge
+// headers open environments that last until the next header, other
+// headers become comments. Runs of tab lines are kept in tab blocks.
+func ImportChordsOverLyrics(content string) string {
+	lines := importLines(content)
+	output := make([]string, 0, len(lines))
+	open := SectionNone
+
+	closeSection := func() {
+		if open == SectionNone {
+			return
+		}
+		// Blank lines before the next header belong outside the section.
+		blanks := 0
+		for blanks < len(output) && output[len(output)-1-blanks] == "" {
+			blanks++
+		}
+		output = append(output[:len(output)-blanks], Directive{"end_of_" + string(open), ""}.String())
+		output = append(output, make([]string, blanks)...)
+		open = SectionNone
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if label, kind, ok := sectionHeader(line); ok {
+			closeSection()
+			if kind == SectionNone {
+				output = append(output, Directive{"comment", label}.String())
+			} else {
+				output = append(output, Directive{"start_of_" + string(kind), label}.String())
+				open = kind
+			}
+			continue
+		}
+
+		if end := tabRunEnd(lines, i); end > i {
+			closeSection()
+			output = append(output, Directive{Name: "start_of_tab"}.String())
+			output = append(output, lines[i:end]...)
+			output = append(output, Directive{Name: "end_of_tab"}.String())
+			i = end - 1
+			continue
+		}
+
+		positions, ok := chordLineChords(line)
+		if !ok {
+			output = append(output, escapeLyrics(line))
+			continue
+		}
+
+		if i+1 < len(lines) && isLyricsLine(lines[i+1]) {
+			output = append(output, placeChords(positions, escapeLyrics(lines[i+1])))
+			i++
+			continue
+		}
+
+		symbols := make([]string, 0, len(positions))
+		for _, position := range positions {
+			if strings.HasPrefix(position.Symbol, "*") {
+				symbols = append(symbols, strings.TrimPrefix(position.Symbol, "*"))
+			} else {
+				symbols = append(symbols, "["+position.Symbol+"]")
+			}
+		}
+		output = append(output, strings.Join(symbols, " "))
+	}
+	closeSection()
+
+	return strings.TrimRight(strings.Join(output, "\n"), "\n")
+}
+
+// tabRunEnd returns the end of the tab staff starting at line i, or i
+// when there is none. A single line of dashes is not taken for a staff.
+func tabRunEnd(lines []string, i int) int {
+	end := i
+	for end < len(lines) {
+		if _, ok := parseTabString(lines[end]); !ok {
+			break
+		}
+		end++
+	}
+	if end-i < 2 {
+		return i
+	}
+	return end
+}
+
+// importLines splits content into lines with tabs expanded and trailing
+// whitespace removed.
+func importLines(content string) []string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(expandTabs(line), " ")
+	}
+	return lines
+}
+
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	column := 0
+	for _, r := range line {
+		if r == '\t' {
+			spaces := importTabWidth - column%importTabWidth
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+			continue
+		}
+		b.WriteRune(r)
+		column++
+	}
+	return b.String()
+}
+
+// chordLineChords returns the chords of a line made only of chords,
+// no-chord markers and chord line extras, with their columns. Markers and
+// extras are returned as annotations, extras prefixed with * as ChordPro
+// writes them. ok is false for any other line.
+func chordLineChords(line string) (positions []ChordPosition, ok bool) {
+	runes := []rune(line)
+	chords := 0
+	for i := 0; i < len(runes); {
+		if runes[i] == ' ' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && runes[i] != ' ' {
+			i++
+		}
+		token := string(runes[start:i])
+
+		if chord, err := ParseChord(strings.Trim(token, "()")); err == nil {
+			positions = append(positions, ChordPosition{Offset: start, Symbol: strings.Trim(token, "()"), Chord: chord})
+			chords++
+			continue
+		}
+		if isNoChord(token) {
+			positions = append(positions, ChordPosition{Offset: start, Symbol: token, Annotation: true})
+			chords++
+			continue
+		}
+		if !chordLineExtras.MatchString(token) {
+			return nil, false
+		}
+		positions = append(positions, ChordPosition{Offset: start, Symbol: "*" + token, Annotation: true})
+	}
+	return positions, chords > 0
+}
+
+func isLyricsLine(line string) bool {
+	if strings.TrimSpace(line) == "" {
+		return false
+	}
+	if _, _, ok := sectionHeader(line); ok {
+		return false
+	}
+	_, ok := chordLineChords(line)
+	return !ok
+}
+
+// sectionHeader recognises a header line and returns its label and the
+// environment it opens, SectionNone for headers that are not one.
+func sectionHeader(line string) (label string, kind SectionKind, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if match := sectionHeaderPattern.FindStringSubmatch(trimmed); match != nil {
+		name := strings.ToLower(match[2])
+		return match[1], sectionEnvironments[name], true
+	}
+	if match := bracketedHeaderPattern.FindStringSubmatch(trimmed); match != nil && !IsChord(match[1]) {
+		return strings.TrimSpace(match[1]), SectionNone, true
+	}
+	return "", SectionNone, false
+}
+
+// placeChords inserts the chords into the lyrics at their columns,
+// padding the lyrics with spaces when a chord is past their end.
+func placeChords(positions []ChordPosition, lyrics string) string {
+	text := []rune(lyrics)
+	var b strings.Builder
+	next := 0
+	for _, position := range positions {
+		if position.Offset > len(text) {
+			text = append(text, []rune(strings.Repeat(" ", position.Offset-len(text)))...)
+		}
+		b.WriteString(string(text[next:position.Offset]))
+		next = position.Offset
+		b.WriteString("[" + position.Symbol + "]")
+	}
+	b.WriteString(string(text[next:]))
+	return b.String()
+}
+
+// escapeLyrics replaces characters ChordPro would read as markup.
+func escapeLyrics(line string) string {
+	line = strings.NewReplacer("[", "(", "]", ")").Replace(line)
+	if trimmed := strings.TrimLeft(line, " "); strings.HasPrefix(trimmed, "{") {
+		line = strings.Replace(line, "{", "(", 1)
+	}
+	return line
+}
@@ -0,0 +1,141 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const pastedSheet = `[Intro]
+Em7  G  Dsus4  A7sus4   x2
+
+[Verse 1]
+Em7            G
+Today is gonna be the day
+             Dsus4                  A7sus4
+That they're gonna throw it back to you
+
+Chorus:
+    C                D                Em
+And all the roads we have to walk are winding
+	C	G
+Short [whispered] line
+N.C.
+I said maybe`
+
+func TestImportChordsOverLyrics(t *testing.T) {
+	assert.True(t, IsChordsOverLyrics(pastedSheet))
+
+	converted := ImportChordsOverLyrics(pastedSheet)
+	assert.Equal(t, `{comment: Intro}
+[Em7] [G] [Dsus4] [A7sus4] x2
+
+{start_of_verse: Verse 1}
+[Em7]Today is gonna [G]be the day
+That they're [Dsus4]gonna throw it back to [A7sus4]you
+{end_of_verse}
+
+{start_of_chorus: Chorus}
+And [C]all the roads we [D]have to walk are [Em]winding
+Short (w[C]hispered[G]) line
+[N.C.]I said maybe
+{end_of_chorus}`, converted)
+
+	sheet, err := ParseChordPro(converted)
+	assert.NoError(t, err, "expected valid ChordPro")
+	assert.Len(t, sheet.Chords(), 13)
+}
+
+func TestImportChordsOverLyrics_CyrillicHeaders(t *testing.T) {
+	pasted := "Куплет\nAm      F\nТёплое место\n\nПрипев 2:\nC     G\nНа улице\n\n[Куплет 1]\nAm\nДень\n\nПроигрыш:\nAm  F"
+
+	assert.Equal(t, `{start_of_verse: Куплет}
+[Am]Тёплое м[F]есто
+{end_of_verse}
+
+{start_of_chorus: Припев 2}
+[C]На ули[G]це
+{end_of_chorus}
+
+{start_of_verse: Куплет 1}
+[Am]День
+{end_of_verse}
+
+{comment: Проигрыш}
+[Am] [F]`, ImportChordsOverLyrics(pasted))
+}
+
+func TestSectionHeader(t *testing.T) {
+	tests := []struct {
+		line  string
+		label string
+		kind  SectionKind
+		ok    bool
+	}{
+		{"[Verse 1]", "Verse 1", SectionVerse, true},
+		{"Chorus:", "Chorus", SectionChorus, true},
+		{"Куплет", "Куплет", SectionVerse, true},
+		{"ПРИПЕВ 2:", "ПРИПЕВ 2", SectionChorus, true},
+		{"[Куплет 1]", "Куплет 1", SectionVerse, true},
+		{"Бридж2", "Бридж2", SectionBridge, true},
+		{"Куплеты нашей жизни", "", SectionNone, false},
+		{"Versed in lies", "", SectionNone, false},
+	}
+
+	for _, tt := range tests {
+		label, kind, ok := sectionHeader(tt.line)
+		assert.Equal(t, tt.ok, ok, tt.line)
+		assert.Equal(t, tt.label, label, tt.line)
+		assert.Equal(t, tt.kind, kind, tt.line)
+	}
+}
+
+func TestImportChordsOverLyrics_ChordsPastLyrics(t *testing.T) {
+	assert.Equal(t, "Hey[D]    [G]", ImportChordsOverLyrics("   D   G\nHey"))
+}
+
+func TestIsChordsOverLyrics(t *testing.T) {
+	tests := map[string]bool{
+		"Am      F\nTeplo mesto":                   true,
+		"[Am]Teplo [F]mesto":                       false,
+		"{title: Song}\nAm F\nla la":               false,
+		"Just some lyrics\nwithout any chords":     false,
+		"[Verse]\nC  G  Am  F\nWhen I find myself": true,
+	}
+
+	for content, expected := range tests {
+		assert.Equal(t, expected, IsChordsOverLyrics(content), content)
+	}
+}
+
+func TestImportChordsOverLyrics_Tab(t *testing.T) {
+	pasted := `[Verse]
+Em          G
+Today is gonna be
+e|-----0---|
+B|---0-----|
+G|-0-------|
+D|---------|
+A|---------|
+E|---------|
+C        D
+The day`
+
+	converted := ImportChordsOverLyrics(pasted)
+	assert.Equal(t, `{start_of_verse: Verse}
+[Em]Today is gon[G]na be
+{end_of_verse}
+{start_of_tab}
+e|-----0---|
+B|---0-----|
+G|-0-------|
+D|---------|
+A|---------|
+E|---------|
+{end_of_tab}
+[C]The day  [D]`, converted)
+
+	sheet, err := ParseChordPro(converted)
+	assert.NoError(t, err)
+	assert.NoError(t, sheet.ValidateTab(StandardGuitar))
+}
@@ -0,0 +1,145 @@
+package chords
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Krumhansl-Kessler key profiles, indexed by semitones above the tonic.
+var (
+	majorProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+var keyPattern = regexp.MustCompile(`^([A-H])([#b♯♭]?)(m|min|minor| minor| major|maj|major)?$`)
+
+// Key is a tonic pitch class and mode.
+type Key struct {
+	Tonic int
+	Minor bool
+}
+
+type KeyScore struct {
+	Key   Key
+	Score float64
+}
+
+// ParseKey parses keys written as chord-like symbols ("Am", "Bb", "F#m")
+// or with the mode spelled out ("A minor").
+func ParseKey(s string) (Key, error) {
+	match := keyPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return Key{}, fmt.Errorf("invalid key %q", s)
+	}
+	mode := strings.TrimSpace(match[3])
+	return Key{
+		Tonic: pitchClass(match[1][0], match[2]),
+		Minor: mode == "m" || mode == "min" || mode == "minor",
+	}, nil
+}
+
+// UsesFlats reports whether the key signature is written with flats.
+func (k Key) UsesFlats() bool {
+	major := k.RelativeMajor().Tonic
+	switch major {
+	case 5, 10, 3, 8, 1: // F, Bb, Eb, Ab, Db
+		return true
+	}
+	return false
+}
+
+// RelativeMajor returns the major key sharing the key signature.
+func (k Key) RelativeMajor() Key {
+	if !k.Minor {
+		return k
+	}
+	return Key{Tonic: mod12(k.Tonic + 3)}
+}
+
+func (k Key) String() string {
+	name := NoteName(k.Tonic, k.UsesFlats())
+	if k.Minor {
+		return name + "m"
+	}
+	return name
+}
+
+// Mode returns "major" or "minor".
+func (k Key) Mode() string {
+	if k.Minor {
+		return "minor"
+	}
+	return "major"
+}
+
+// DetectKey returns the most likely key for a chord sequence. ok is false
+// when there are no chords to analyse.
+func DetectKey(sequence []Chord) (key Key, ok bool) {
+	ranked := RankKeys(sequence)
+	if len(ranked) == 0 {
+		return Key{}, false
+	}
+	return ranked[0].Key, true
+}
+
+// RankKeys scores all 24 keys by correlating the sequence's weighted
+// pitch-class profile with the Krumhansl-Kessler key profiles, best first.
+func RankKeys(sequence []Chord) []KeyScore {
+	if len(sequence) == 0 {
+		return []KeyScore{}
+	}
+
+	var profile [12]float64
+	for i, chord := range sequence {
+		for _, pitch := range chord.PitchClasses() {
+			profile[pitch]++
+		}
+		profile[chord.Root]++
+		if chord.HasBass {
+			profile[chord.Bass] += 0.5
+		}
+		// Songs tend to start and end on the tonic.
+		if i == 0 || i == len(sequence)-1 {
+			profile[chord.Root] += 2
+		}
+	}
+
+	scores := make([]KeyScore, 0, 24)
+	for tonic := 0; tonic < 12; tonic++ {
+		scores = append(scores,
+			KeyScore{Key{tonic, false}, correlate(profile, majorProfile, tonic)},
+			KeyScore{Key{tonic, true}, correlate(profile, minorProfile, tonic)},
+		)
+	}
+
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	return scores
+}
+
+func correlate(profile, keyProfile [12]float64, tonic int) float64 {
+	var profileMean, keyMean float64
+	for i := 0; i < 12; i++ {
+		profileMean += profile[i]
+		keyMean += keyProfile[i]
+	}
+	profileMean /= 12
+	keyMean /= 12
+
+	var covariance, profileVariance, keyVariance float64
+	for pitch := 0; pitch < 12; pitch++ {
+		x := profile[pitch] - profileMean
+		y := keyProfile[mod12(pitch-tonic)] - keyMean
+		covariance += x * y
+		profileVariance += x * x
+		keyVariance += y * y
+	}
+	if profileVariance == 0 || keyVariance == 0 {
+		return 0
+	}
+	return covariance / math.Sqrt(profileVariance*keyVariance)
+}
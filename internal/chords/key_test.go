@@ -0,0 +1,76 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseSequence(t *testing.T, symbols ...string) []Chord {
+	sequence := make([]Chord, 0, len(symbols))
+	for _, symbol := range symbols {
+		chord, err := ParseChord(symbol)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		sequence = append(sequence, chord)
+	}
+	return sequence
+}
+
+func TestDetectKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		sequence []string
+		key      string
+	}{
+		{"C major", []string{"C", "Am", "F", "G", "C"}, "C"},
+		{"G major", []string{"G", "D", "Em", "C", "G"}, "G"},
+		{"A minor", []string{"Am", "Dm", "E7", "Am"}, "Am"},
+		{"E minor", []string{"Em", "C", "D", "B7", "Em"}, "Em"},
+		{"Bb major", []string{"Bb", "Eb", "F", "Bb"}, "Bb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := DetectKey(parseSequence(t, tt.sequence...))
+			assert.True(t, ok)
+			assert.Equal(t, tt.key, key.String())
+		})
+	}
+}
+
+func TestDetectKey_Empty(t *testing.T) {
+	_, ok := DetectKey(nil)
+	assert.False(t, ok)
+}
+
+func TestParseKey(t *testing.T) {
+	tests := map[string]Key{
+		"C":        {0, false},
+		"Am":       {9, true},
+		"F#m":      {6, true},
+		"Bb":       {10, false},
+		"A minor":  {9, true},
+		"Eb major": {3, false},
+	}
+	for s, expected := range tests {
+		key, err := ParseKey(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, key, s)
+	}
+
+	_, err := ParseKey("X")
+	assert.Error(t, err)
+}
+
+func TestProgressionTokens(t *testing.T) {
+	inC := ProgressionTokens(parseSequence(t, "Am", "F", "C", "G", "G"), Key{0, false})
+	assert.Equal(t, []string{"vi", "IV", "I", "V"}, inC)
+
+	inAMinor := ProgressionTokens(parseSequence(t, "Am", "F", "C", "G"), Key{9, true})
+	assert.Equal(t, inC, inAMinor, "expected relative keys to share tokens")
+
+	inG := ProgressionTokens(parseSequence(t, "Em", "C", "G", "D", "F#dim", "Bb"), Key{7, false})
+	assert.Equal(t, []string{"vi", "IV", "I", "V", "vii°", "bIII"}, inG)
+}
@@ -0,0 +1,124 @@
+package chords
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Notation is how chords are written on a rendered sheet.
+type Notation string
+
+const (
+	// LetterNotation writes chords as symbols, the way they are stored.
+	LetterNotation Notation = "chords"
+	// NashvilleNotation writes chords as scale degree numbers relative to
+	// the tonic, "1 4 5m" in major keys and "1m b6 b7" in minor ones.
+	NashvilleNotation Notation = "nashville"
+	// RomanNotation writes chords as Roman numerals as Degree does.
+	RomanNotation Notation = "roman"
+)
+
+var Notations = []Notation{LetterNotation, NashvilleNotation, RomanNotation}
+
+var nashvilleDegrees = [12]string{"1", "b2", "2", "b3", "3", "4", "b5", "5", "b6", "6", "b7", "7"}
+
+func ParseNotation(s string) (Notation, error) {
+	for _, notation := range Notations {
+		if string(notation) == s {
+			return notation, nil
+		}
+	}
+	return "", fmt.Errorf("invalid notation %q", s)
+}
+
+// Nashville writes the chord as a Nashville number relative to the key:
+// the degree of the root counted on the major scale of the tonic, the
+// quality (m, °, ø or +), the rest of the suffix and the degree of a
+// slash bass, so G7/B in C is "57/7".
+func (c Chord) Nashville(key Key) string {
+	number := nashvilleDegrees[mod12(c.Root-key.Tonic)]
+	switch c.Quality() {
+	case Minor:
+		number += "m"
+	case Diminished:
+		number += "°"
+	case HalfDiminished:
+		number += "ø"
+	case Augmented:
+		number += "+"
+	}
+	return number + c.extension() + c.bassDegree(key)
+}
+
+// Roman writes the chord as its Roman numeral degree followed by the rest
+// of the suffix, so Am7 in C is "vi7". A slash bass is written as a
+// Nashville number, "V/7", so it is not mistaken for a secondary chord.
+func (c Chord) Roman(key Key) string {
+	return Degree(c, key) + c.extension() + c.bassDegree(key)
+}
+
+func (c Chord) bassDegree(key Key) string {
+	if !c.HasBass {
+		return ""
+	}
+	return "/" + nashvilleDegrees[mod12(c.Bass-key.Tonic)]
+}
+
+// extension is the suffix without the part spelling the chord's quality.
+func (c Chord) extension() string {
+	s := c.Suffix
+	switch c.Quality() {
+	case HalfDiminished:
+		if rest, ok := strings.CutPrefix(s, "ø"); ok {
+			return rest
+		}
+		return strings.NewReplacer("m7b5", "7", "m7♭5", "7").Replace(s)
+	case Diminished:
+		return trimAnyPrefix(s, "dim", "°")
+	case Augmented:
+		return trimAnyPrefix(s, "aug", "+")
+	case Minor:
+		return trimAnyPrefix(s, "min", "mi", "m", "-")
+	}
+	return s
+}
+
+func trimAnyPrefix(s string, prefixes ...string) string {
+	for _, prefix := range prefixes {
+		if rest, ok := strings.CutPrefix(s, prefix); ok {
+			return rest
+		}
+	}
+	return s
+}
+
+// ApplyNotation rewrites the chord symbols of the sheet in the notation
+// relative to the sheet's key. The sheet is left alone when the notation
+// is LetterNotation or the key is unknown. Symbols in other notations are
+// not valid ChordPro chords, so the sheet should only be rendered after.
+func (s *Sheet) ApplyNotation(notation Notation) {
+	if notation == LetterNotation || notation == "" {
+		return
+	}
+	key, ok := s.Key()
+	if !ok {
+		return
+	}
+
+	for i := range s.Sections {
+		lines := s.Sections[i].Lines
+		for j := range lines {
+			for k := range lines[j].Chords {
+				position := &lines[j].Chords[k]
+				if position.Annotation {
+					continue
+				}
+				if notation == NashvilleNotation {
+					position.Symbol = position.Chord.Nashville(key)
+				} else {
+					position.Symbol = position.Chord.Roman(key)
+				}
+			}
+		}
+	}
+}
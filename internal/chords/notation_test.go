@@ -0,0 +1,66 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChordNotations(t *testing.T) {
+	c, _ := ParseKey("C")
+	am, _ := ParseKey("Am")
+
+	tests := []struct {
+		symbol    string
+		key       Key
+		nashville string
+		roman     string
+	}{
+		{"C", c, "1", "I"},
+		{"Am7", c, "6m7", "vi7"},
+		{"G7/B", c, "57/7", "V7/7"},
+		{"Bdim", c, "7°", "vii°"},
+		{"Bm7b5", c, "7ø7", "viiø7"},
+		{"Eaug", c, "3+", "III+"},
+		{"Cmaj7", c, "1maj7", "Imaj7"},
+		{"Dsus4", c, "2sus4", "IIsus4"},
+		{"Bb", c, "b7", "bVII"},
+		{"Am", am, "1m", "i"},
+		{"F", am, "b6", "VI"},
+		{"E7", am, "57", "V7"},
+		{"C/G", am, "b3/b7", "III/b7"},
+		{"Dmadd9", am, "4madd9", "ivadd9"},
+	}
+
+	for _, tt := range tests {
+		chord, err := ParseChord(tt.symbol)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.nashville, chord.Nashville(tt.key), "%s in %s", tt.symbol, tt.key)
+		assert.Equal(t, tt.roman, chord.Roman(tt.key), "%s in %s", tt.symbol, tt.key)
+	}
+}
+
+func TestSheet_ApplyNotation(t *testing.T) {
+	sheet, err := ParseChordPro("{key: G}\n[G]Hello [Em]darkness my [C/E]old [D7]friend\n[*N.C.]Silence")
+	assert.NoError(t, err)
+
+	nashville := sheet.Clone()
+	nashville.ApplyNotation(NashvilleNotation)
+	assert.Equal(t, "{key: G}\n[1]Hello [6m]darkness my [4/6]old [57]friend\n[*N.C.]Silence", nashville.String())
+
+	roman := sheet.Clone()
+	roman.ApplyNotation(RomanNotation)
+	assert.Equal(t, "{key: G}\n[I]Hello [vi]darkness my [IV/6]old [V7]friend\n[*N.C.]Silence", roman.String())
+
+	sheet.ApplyNotation(LetterNotation)
+	assert.Equal(t, "{key: G}\n[G]Hello [Em]darkness my [C/E]old [D7]friend\n[*N.C.]Silence", sheet.String())
+}
+
+func TestParseNotation(t *testing.T) {
+	notation, err := ParseNotation("nashville")
+	assert.NoError(t, err)
+	assert.Equal(t, NashvilleNotation, notation)
+
+	_, err = ParseNotation("tabs")
+	assert.Error(t, err)
+}
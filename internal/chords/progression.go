@@ -0,0 +1,73 @@
+package chords
+
+import (
+	"regexp"
+	"strings"
+)
+
+var inlineChordPattern = regexp.MustCompile(`\[([^\]]*)\]`)
+
+var (
+	majorDegrees = [12]string{"I", "bII", "II", "bIII", "III", "IV", "bV", "V", "bVI", "VI", "bVII", "VII"}
+	minorDegrees = [12]string{"I", "bII", "II", "III", "#III", "IV", "bV", "V", "VI", "#VI", "VII", "#VII"}
+)
+
+// ExtractChords returns the inline [chord] symbols of song content in
+// order, skipping symbols that do not parse.
+func ExtractChords(content string) []Chord {
+	sequence := make([]Chord, 0)
+	for _, match := range inlineChordPattern.FindAllStringSubmatch(content, -1) {
+		chord, err := ParseChord(match[1])
+		if err != nil {
+			continue
+		}
+		sequence = append(sequence, chord)
+	}
+	return sequence
+}
+
+// Degree returns the Roman numeral of the chord root relative to the key,
+// uppercase for major-sounding chords and lowercase for minor ones, with
+// ° for diminished, ø for half-diminished and + for augmented chords.
+func Degree(chord Chord, key Key) string {
+	numeral := degreeName(chord.Root, key)
+
+	switch chord.Quality() {
+	case Minor:
+		return strings.ToLower(numeral)
+	case Diminished:
+		return strings.ToLower(numeral) + "°"
+	case HalfDiminished:
+		return strings.ToLower(numeral) + "ø"
+	case Augmented:
+		return numeral + "+"
+	default:
+		return numeral
+	}
+}
+
+func degreeName(pitch int, key Key) string {
+	offset := mod12(pitch - key.Tonic)
+	if key.Minor {
+		return minorDegrees[offset]
+	}
+	return majorDegrees[offset]
+}
+
+// ProgressionTokens normalises a chord sequence into scale degrees
+// relative to the major key sharing the key signature, so that a song in
+// A minor and one in C major with the same chords produce the same tokens.
+// Repeated chords are collapsed.
+func ProgressionTokens(sequence []Chord, key Key) []string {
+	major := key.RelativeMajor()
+
+	tokens := make([]string, 0, len(sequence))
+	for _, chord := range sequence {
+		token := Degree(chord, major)
+		if len(tokens) > 0 && tokens[len(tokens)-1] == token {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
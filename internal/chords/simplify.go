@@ -0,0 +1,63 @@
+package chords
+
+// MaxSuggestedCapo is the highest fret BestCapo suggests. Shapes higher up
+// the neck get cramped for beginners.
+const MaxSuggestedCapo = 7
+
+// Simplify reduces the chord to the closest basic triad: minor for minor,
+// diminished and half-diminished chords, major for everything else.
+// Open shapes such as E7 or Cmaj7 are kept. The slash bass is dropped.
+func (c Chord) Simplify() Chord {
+	c.HasBass = false
+	if IsOpenShape(c) {
+		return c
+	}
+
+	switch c.Quality() {
+	case Minor, Diminished, HalfDiminished:
+		c.Suffix = "m"
+	default:
+		c.Suffix = ""
+	}
+	return c
+}
+
+// Simplify replaces every chord of the sheet with Chord.Simplify.
+func (s *Sheet) Simplify() {
+	for i := range s.Sections {
+		lines := s.Sections[i].Lines
+		for j := range lines {
+			for k := range lines[j].Chords {
+				position := &lines[j].Chords[k]
+				if position.Annotation {
+					continue
+				}
+				position.Chord = position.Chord.Simplify()
+				position.Symbol = position.Chord.String()
+			}
+		}
+	}
+}
+
+// BestCapo returns the capo fret, up to MaxSuggestedCapo, at which the
+// concert chords need the fewest barre chords, then the lowest total
+// ChordCost. Lower frets win ties.
+func BestCapo(sequence []Chord) uint {
+	distinct := distinctChords(sequence)
+
+	best, bestBarres, bestCost := uint(0), 0, 0.0
+	for capo := uint(0); capo <= MaxSuggestedCapo; capo++ {
+		barres, cost := 0, 0.0
+		for _, chord := range distinct {
+			shape := chord.Transpose(-int(capo))
+			if !IsOpenShape(shape) {
+				barres++
+			}
+			cost += ChordCost(shape)
+		}
+		if capo == 0 || barres < bestBarres || (barres == bestBarres && cost < bestCost) {
+			best, bestBarres, bestCost = capo, barres, cost
+		}
+	}
+	return best
+}
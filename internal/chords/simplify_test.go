@@ -0,0 +1,54 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChord_Simplify(t *testing.T) {
+	tests := map[string]string{
+		"Cmaj9":   "C",
+		"G7sus4":  "G",
+		"Bm7b5":   "Bm",
+		"F#dim7":  "F#m",
+		"Ebaug":   "Eb",
+		"Bbm9":    "Bbm",
+		"E7":      "E7",
+		"Cmaj7":   "Cmaj7",
+		"G/B":     "G",
+		"Am7/G":   "Am7",
+		"F#5":     "F#",
+		"C#m11/E": "C#m",
+	}
+
+	for symbol, expected := range tests {
+		chord, err := ParseChord(symbol)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, chord.Simplify().String(), symbol)
+	}
+}
+
+func TestSheet_Simplify(t *testing.T) {
+	sheet, err := ParseChordPro("[Cmaj9]Some [G7sus4/D]words [*riff]here")
+	assert.NoError(t, err)
+
+	sheet.Simplify()
+	assert.Equal(t, "[C]Some [G]words [*riff]here", sheet.String())
+}
+
+func TestBestCapo(t *testing.T) {
+	sequence := func(symbols ...string) []Chord {
+		chords := make([]Chord, 0, len(symbols))
+		for _, symbol := range symbols {
+			chord, _ := ParseChord(symbol)
+			chords = append(chords, chord)
+		}
+		return chords
+	}
+
+	assert.Equal(t, uint(0), BestCapo(sequence("G", "C", "D", "Em")), "expected open chords to need no capo")
+	assert.Equal(t, uint(1), BestCapo(sequence("Ab", "Db", "Eb", "Fm")), "expected G C D Em shapes")
+	assert.Equal(t, uint(3), BestCapo(sequence("Bb", "Eb", "F", "Gm")), "expected G C D Em shapes")
+	assert.Equal(t, uint(0), BestCapo(nil))
+}
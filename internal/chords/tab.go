@@ -0,0 +1,299 @@
+package chords
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxTabFret is the highest fret a tab note can be on.
+const MaxTabFret = 24
+
+// TabStaff is a group of consecutive tab lines, one per string with the
+// highest string first as tabs are written.
+type TabStaff struct {
+	Strings []TabString
+}
+
+// TabString is a tab line. Line is its index in the section's lines and
+// Name the string name written before the bar, empty when there is none.
+type TabString struct {
+	Line  int
+	Name  string
+	Notes []TabNote
+}
+
+// TabNote is a fret played at Position, the rune offset of its first
+// digit in the line text. A dead note (x) has a Fret of -1.
+type TabNote struct {
+	Position int
+	Fret     int
+}
+
+// tabLinePattern splits a tab line into its string name, bar and content.
+// The content ends at the first space so "|--0--| x4" is still a string.
+var tabLinePattern = regexp.MustCompile(`^(\s*(?:([A-Ga-g][#b]?)\s*)?[|:]?)([-0-9|:hpbrxX/\\~().<>^*=sStTvV]+)`)
+
+const minTabDashes = 3
+
+// parseTabString reads a line of a tab block as a string of a staff. ok is
+// false for other lines, like chord names or labels over the staff.
+func parseTabString(text string) (tabString TabString, ok bool) {
+	match := tabLinePattern.FindStringSubmatchIndex(text)
+	if match == nil {
+		return TabString{}, false
+	}
+	content := text[match[6]:match[7]]
+	if strings.Count(content, "-") < minTabDashes {
+		return TabString{}, false
+	}
+	if match[4] >= 0 {
+		tabString.Name = text[match[4]:match[5]]
+	}
+
+	// The pattern only matches ASCII, so byte offsets are rune offsets.
+	start := match[6]
+	tabString.Notes = make([]TabNote, 0)
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; {
+		case c >= '0' && c <= '9':
+			end := i
+			for end < len(content) && content[end] >= '0' && content[end] <= '9' {
+				end++
+			}
+			fret, _ := strconv.Atoi(content[i:end])
+			tabString.Notes = append(tabString.Notes, TabNote{Position: start + i, Fret: fret})
+			i = end - 1
+		case c == 'x' || c == 'X':
+			tabString.Notes = append(tabString.Notes, TabNote{Position: start + i, Fret: -1})
+		}
+	}
+	return tabString, true
+}
+
+// parseTabStaves groups the tab lines of a section into staves.
+func parseTabStaves(lines []Line) []TabStaff {
+	staves := make([]TabStaff, 0)
+	var staff *TabStaff
+	for i, line := range lines {
+		tabString, ok := parseTabString(line.Text)
+		if line.Kind != TabLine || !ok {
+			staff = nil
+			continue
+		}
+		tabString.Line = i
+		if staff == nil {
+			staves = append(staves, TabStaff{})
+			staff = &staves[len(staves)-1]
+		}
+		staff.Strings = append(staff.Strings, tabString)
+	}
+	return staves
+}
+
+// ValidateTab checks that no staff of the tab blocks has more strings
+// than the tuning and that named strings are strings of the tuning.
+// Riffs are often written on the strings they use only, so a staff may
+// have fewer strings. Its strings are matched from the highest down, a
+// named string skipping the strings the staff leaves out; unnamed
+// strings are taken to be the next ones. Problems are returned as
+// ParseErrors.
+func (s *Sheet) ValidateTab(tuning Tuning) error {
+	errors := make(ParseErrors, 0)
+	for _, section := range s.Sections {
+		for _, staff := range section.Staves {
+			first := section.Lines[staff.Strings[0].Line].Number
+			if len(staff.Strings) > len(tuning.Strings) {
+				errors = append(errors, &ParseError{first, 1, tabTuningMessage(len(staff.Strings), tuning)})
+				continue
+			}
+
+			// Tabs list the highest string first, tunings the lowest.
+			open := func(i int) int { return tuning.Strings[len(tuning.Strings)-1-i] }
+			skippable := len(tuning.Strings) - len(staff.Strings)
+			next := 0
+			for i, tabString := range staff.Strings {
+				if tabString.Name == "" {
+					next++
+					continue
+				}
+				name := tabString.Name
+				pitch := pitchClass(strings.ToUpper(name[:1])[0], name[1:])
+
+				match := -1
+				for j := next; j <= next+skippable; j++ {
+					if pitch == mod12(open(j)) {
+						match = j
+						break
+					}
+				}
+				if match < 0 {
+					line := section.Lines[tabString.Line]
+					column := strings.Index(line.Text, name) + 1
+					errors = append(errors, &ParseError{line.Number, column, tabStringMessage(i, name, pitch, open(next), tuning)})
+					// Leave the string out rather than report the ones
+					// after it as well.
+					skippable++
+					continue
+				}
+				skippable -= match - next
+				next = match + 1
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+func tabStringMessage(i int, name string, pitch, expected int, tuning Tuning) string {
+	message := "tab string " + strconv.Itoa(i+1) + " is " + name + ", " + tuning.Name + " tuning has "
+	for _, open := range tuning.Strings {
+		if mod12(open) == pitch {
+			return message + NoteName(expected, false)
+		}
+	}
+	return message + "no " + name + " string"
+}
+
+func tabTuningMessage(count int, tuning Tuning) string {
+	return "tab staff has " + strconv.Itoa(count) + " strings, " + string(tuning.Instrument) +
+		" in " + tuning.Name + " tuning has " + strconv.Itoa(len(tuning.Strings))
+}
+
+// transposeTab moves every note of the section's staves by semitones,
+// played on the same string. A staff that would go below the nut or past
+// MaxTabFret is moved by another octave when that keeps it on the neck.
+func transposeTab(section *Section, semitones int) {
+	// The chords only tell the pitch class, take the shortest way there.
+	shift := mod12(semitones)
+	if shift > 6 {
+		shift -= 12
+	}
+
+	for _, staff := range section.Staves {
+		lowest, highest := MaxTabFret, 0
+		for _, tabString := range staff.Strings {
+			for _, note := range tabString.Notes {
+				if note.Fret >= 0 {
+					lowest, highest = min(lowest, note.Fret), max(highest, note.Fret)
+				}
+			}
+		}
+		if lowest > highest {
+			continue
+		}
+
+		staffShift := shift
+		switch {
+		case lowest+staffShift < 0:
+			staffShift += 12
+		case highest+staffShift > MaxTabFret && lowest+staffShift-12 >= 0:
+			staffShift -= 12
+		}
+		rewriteStaff(section.Lines, staff, staffShift)
+	}
+	section.Staves = parseTabStaves(section.Lines)
+}
+
+// rewriteStaff rewrites the lines of a staff with every fret moved by
+// shift. Columns are widened on all strings when a fret gains a digit,
+// and narrowed again when a fret loses one and only dashes would go, so
+// the strings stay aligned.
+func rewriteStaff(lines []Line, staff TabStaff, shift int) {
+	texts := make([][]rune, len(staff.Strings))
+	notes := make([]map[int]TabNote, len(staff.Strings))
+	positions := make([]int, 0)
+	seen := make(map[int]bool)
+	for i, tabString := range staff.Strings {
+		texts[i] = []rune(lines[tabString.Line].Text)
+		notes[i] = make(map[int]TabNote)
+		for _, note := range tabString.Notes {
+			if note.Fret < 0 {
+				continue
+			}
+			notes[i][note.Position] = note
+			if !seen[note.Position] {
+				seen[note.Position] = true
+				positions = append(positions, note.Position)
+			}
+		}
+	}
+	sort.Ints(positions)
+
+	written := make([][]rune, len(texts))
+	span := func(i, from, to int) []rune {
+		from, to = min(from, len(texts[i])), min(to, len(texts[i]))
+		if from >= to {
+			return nil
+		}
+		return texts[i][from:to]
+	}
+
+	cursor := 0
+	for _, position := range positions {
+		// A cell is the columns the widest old fret took. Its runes after
+		// a fret, like the h of 9h10, are kept.
+		oldWidth := 0
+		for i := range texts {
+			if note, ok := notes[i][position]; ok {
+				oldWidth = max(oldWidth, len(strconv.Itoa(note.Fret)))
+			}
+		}
+
+		frets := make([]string, len(texts))
+		rests := make([][]rune, len(texts))
+		width := 0
+		for i := range texts {
+			cell := span(i, position, position+oldWidth)
+			if note, ok := notes[i][position]; ok {
+				frets[i] = strconv.Itoa(note.Fret + shift)
+				cell = cell[min(len(strconv.Itoa(note.Fret)), len(cell)):]
+			}
+			rests[i] = cell
+			width = max(width, len(frets[i])+len(strings.Trim(string(cell), "-")))
+		}
+
+		for i := range texts {
+			written[i] = append(written[i], span(i, cursor, position)...)
+			if frets[i] == "" && position >= len(texts[i]) {
+				continue
+			}
+			written[i] = append(written[i], fitTabCell(frets[i], rests[i], width)...)
+		}
+		cursor = position + oldWidth
+	}
+
+	for i, tabString := range staff.Strings {
+		written[i] = append(written[i], span(i, cursor, len(texts[i]))...)
+		lines[tabString.Line].Text = string(written[i])
+	}
+}
+
+// fitTabCell writes a fret followed by the rest of its cell in width
+// columns, adding dashes after the fret or dropping dashes of the rest.
+// fret is empty for strings without a fret in the cell.
+func fitTabCell(fret string, rest []rune, width int) []rune {
+	cell := []rune(fret)
+	excess := len(cell) + len(rest) - width
+	if excess <= 0 {
+		padding := []rune(strings.Repeat("-", -excess))
+		if fret == "" {
+			// A string without a fret here keeps its mark, like a dead
+			// note, in the column.
+			return append(append(cell, rest...), padding...)
+		}
+		return append(append(cell, padding...), rest...)
+	}
+
+	for excess > 0 && len(rest) > 0 && rest[0] == '-' {
+		rest, excess = rest[1:], excess-1
+	}
+	for excess > 0 && len(rest) > 0 && rest[len(rest)-1] == '-' {
+		rest, excess = rest[:len(rest)-1], excess-1
+	}
+	return append(cell, rest...)
+}
@@ -0,0 +1,151 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const riff = `{title: Riff}
+[Em]Intro
+{start_of_tab: Riff}
+  Em
+e|-------0-----|
+B|-----0---0---|
+G|---0-------0-|
+D|-2-----------|
+A|-------------| x2
+E|-0-----------|
+
+# played twice
+|--7h9--x--|
+{end_of_tab}`
+
+func TestParseTab(t *testing.T) {
+	sheet, err := ParseChordPro(riff)
+	assert.NoError(t, err)
+
+	tab := sheet.Sections[1]
+	assert.Equal(t, SectionTab, tab.Kind)
+	assert.Equal(t, "Riff", tab.Label)
+	assert.Equal(t, TabLine, tab.Lines[0].Kind, "expected chord names over the staff to be kept")
+	assert.Equal(t, "# played twice", tab.Lines[8].Text, "expected # to be a tab line, not a comment")
+	assert.Empty(t, tab.Lines[1].Chords)
+
+	if assert.Len(t, tab.Staves, 2) {
+		staff := tab.Staves[0]
+		assert.Len(t, staff.Strings, 6)
+		assert.Equal(t, TabString{Line: 1, Name: "e", Notes: []TabNote{{9, 0}}}, staff.Strings[0])
+		assert.Equal(t, []TabNote{{3, 2}}, staff.Strings[3].Notes)
+		assert.Equal(t, "A", staff.Strings[4].Name)
+
+		assert.Equal(t, []TabNote{{3, 7}, {5, 9}, {8, -1}}, tab.Staves[1].Strings[0].Notes)
+	}
+
+	assert.Equal(t, riff, sheet.String())
+	assert.Equal(t, []Chord{{Root: 4, Suffix: "m"}}, sheet.Chords(), "expected tab lines to hold no chords")
+}
+
+func TestParseTab_Errors(t *testing.T) {
+	_, err := ParseChordPro("{sot}\ne|--25--|\n{eot}")
+	assert.EqualError(t, err, "line 2, column 5: fret 25 is above 24")
+
+	_, err = ParseChordPro("{start_of_tab}\ne|--0--|")
+	assert.EqualError(t, err, "line 1, column 1: start_of_tab is not closed")
+}
+
+func TestValidateTab(t *testing.T) {
+	sheet, err := ParseChordPro(riff)
+	assert.NoError(t, err)
+
+	assert.NoError(t, sheet.ValidateTab(StandardGuitar), "expected a staff of one string to be valid")
+	assert.EqualError(t, sheet.ValidateTab(StandardUkulele),
+		"line 5, column 1: tab staff has 6 strings, ukulele in standard tuning has 4")
+
+	sheet, _ = ParseChordPro("{sot}\ne|--0--|\nB|--0--|\nG|--0--|\nD|--0--|\nA|--0--|\nD|--0--|\n{eot}")
+	assert.EqualError(t, sheet.ValidateTab(StandardGuitar), "line 7, column 1: tab string 6 is D, standard tuning has E")
+
+	// Staves of the strings a riff uses, by name or from the highest.
+	sheet, _ = ParseChordPro("{sot}\nG|--0--|\nD|--2--|\nA|--2--|\n\ne|--0--|\nB|--1--|\n\nD|--0--|\nE|--0--|\n{eot}")
+	assert.NoError(t, sheet.ValidateTab(StandardGuitar))
+
+	sheet, _ = ParseChordPro("{sot}\nB|--0--|\nF|--2--|\nG|--2--|\n{eot}")
+	assert.EqualError(t, sheet.ValidateTab(StandardGuitar), "line 3, column 1: tab string 2 is F, standard tuning has no F string")
+
+	sheet, _ = ParseChordPro("{sot}\nA|--0--|\nD|--2--|\n{eot}")
+	assert.EqualError(t, sheet.ValidateTab(StandardGuitar), "line 3, column 1: tab string 2 is D, standard tuning has E",
+		"expected strings to go from the highest down")
+
+	sheet, _ = ParseChordPro("{sot}\nE|--0--|\nB|--0--|\nG|--0--|\nD|--0--|\nA|--0--|\nE|--0--|\n\n-----0--\n-----0--\n-----0--\n-----0--\n-----0--\n-----0--\n{eot}")
+	assert.NoError(t, sheet.ValidateTab(StandardGuitar))
+}
+
+func TestTransposeTab(t *testing.T) {
+	tests := []struct {
+		name      string
+		tab       string
+		semitones int
+		expected  string
+	}{
+		{
+			name:      "keeps columns when widths do not change",
+			tab:       "e|--3--|\nB|--5--|",
+			semitones: 2,
+			expected:  "e|--5--|\nB|--7--|",
+		},
+		{
+			name:      "widens every string for two digit frets",
+			tab:       "e|--8--9--|\nB|--5-----|",
+			semitones: 2,
+			expected:  "e|--10--11--|\nB|--7-------|",
+		},
+		{
+			name:      "narrows back to dashes",
+			tab:       "e|--10--11--|\nB|--7-------|",
+			semitones: -2,
+			expected:  "e|--8--9--|\nB|--5-----|",
+		},
+		{
+			name:      "keeps techniques after frets",
+			tab:       "e|--9h10--|\nB|--------|",
+			semitones: 1,
+			expected:  "e|--10h11--|\nB|---------|",
+		},
+		{
+			name:      "moves an octave up instead of below the nut",
+			tab:       "e|--0--2--|\nB|--x-----|",
+			semitones: -2,
+			expected:  "e|--10--12--|\nB|--x-------|",
+		},
+		{
+			name:      "takes the shortest way to the key",
+			tab:       "e|--5--|",
+			semitones: 10,
+			expected:  "e|--3--|",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet, err := ParseChordPro("{start_of_tab}\n" + tt.tab + "\n{end_of_tab}")
+			assert.NoError(t, err)
+
+			sheet.Transpose(tt.semitones)
+			expected := "{start_of_tab}\n" + tt.expected + "\n{end_of_tab}"
+			assert.Equal(t, expected, sheet.String())
+
+			reparsed, _ := ParseChordPro(expected)
+			assert.Equal(t, reparsed.Sections[0].Staves, sheet.Sections[0].Staves, "expected staves to follow the new text")
+		})
+	}
+}
+
+func TestTransposeTab_LeavesCloneAlone(t *testing.T) {
+	sheet, _ := ParseChordPro("[C]Riff\n{sot}\ne|--3--|\n{eot}")
+
+	clone := sheet.Clone()
+	clone.Transpose(2)
+
+	assert.Contains(t, clone.String(), "e|--5--|")
+	assert.Equal(t, "[C]Riff\n{start_of_tab}\ne|--3--|\n{end_of_tab}", sheet.String())
+}
@@ -0,0 +1,75 @@
+package chords
+
+// Transpose shifts the root and bass of the chord by semitones.
+func (c Chord) Transpose(semitones int) Chord {
+	c.Root = mod12(c.Root + semitones)
+	if c.HasBass {
+		c.Bass = mod12(c.Bass + semitones)
+	}
+	return c
+}
+
+// Transpose shifts the key's tonic by semitones.
+func (k Key) Transpose(semitones int) Key {
+	k.Tonic = mod12(k.Tonic + semitones)
+	return k
+}
+
+// Transpose shifts every chord of the sheet by semitones and spells them
+// with sharps or flats according to the key signature of the target key.
+// A {key} directive is rewritten to the target key and tab blocks are
+// moved along. Annotations are left alone.
+func (s *Sheet) Transpose(semitones int) {
+	if mod12(semitones) == 0 {
+		return
+	}
+
+	key, hasKey := s.Key()
+	target := key.Transpose(semitones)
+
+	for i := range s.Sections {
+		lines := s.Sections[i].Lines
+		for j := range lines {
+			line := &lines[j]
+			if line.Kind == DirectiveLine && line.Directive.Name == "key" && hasKey {
+				line.Directive.Value = target.String()
+			}
+			for k := range line.Chords {
+				position := &line.Chords[k]
+				if position.Annotation {
+					continue
+				}
+				flats := position.Chord.Flat
+				if hasKey {
+					flats = target.UsesFlats()
+				}
+				position.Chord = position.Chord.Transpose(semitones)
+				position.Chord.Flat = flats
+				position.Symbol = position.Chord.String()
+			}
+		}
+		if s.Sections[i].Kind == SectionTab {
+			transposeTab(&s.Sections[i], semitones)
+		}
+	}
+}
+
+// Key returns the key from the {key} directive, or the key detected from
+// the chords when there is none. ok is false when neither is available.
+func (s *Sheet) Key() (key Key, ok bool) {
+	if value := s.DirectiveValue("key"); value != "" {
+		if key, err := ParseKey(value); err == nil {
+			return key, true
+		}
+	}
+	return DetectKey(s.Chords())
+}
+
+// ContentKey returns the key of ChordPro content like Sheet.Key, falling
+// back to the inline chords alone when the content does not parse.
+func ContentKey(content string) (key Key, ok bool) {
+	if sheet, err := ParseChordPro(content); err == nil {
+		return sheet.Key()
+	}
+	return DetectKey(ExtractChords(content))
+}
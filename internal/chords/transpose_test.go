@@ -0,0 +1,87 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChordTranspose(t *testing.T) {
+	tests := []struct {
+		symbol    string
+		semitones int
+		flats     bool
+		expected  string
+	}{
+		{"C", 2, false, "D"},
+		{"Am", -2, false, "Gm"},
+		{"Am", 1, true, "Bbm"},
+		{"Cmaj7/G", 1, false, "C#maj7/G#"},
+		{"Cmaj7/G", 1, true, "Dbmaj7/Ab"},
+		{"F#m7b5", -1, false, "Fm7b5"},
+		{"Bb7sus4", 2, false, "C7sus4"},
+		{"Ebadd9", 3, false, "F#add9"},
+		{"Ebadd9", 3, true, "Gbadd9"},
+		{"G/B", -12, false, "G/B"},
+		{"E", 13, false, "F"},
+		{"Hm", 1, false, "Cm"},
+		{"D7/F#", -7, false, "G7/B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			chord, err := ParseChord(tt.symbol)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, chord.Transpose(tt.semitones).Spell(tt.flats))
+		})
+	}
+}
+
+func TestSheetTranspose(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		semitones int
+		expected  string
+	}{
+		{
+			"down to a flat key",
+			"[G]Today is [D/F#]gonna be [Em7]the [Cadd9]day",
+			-2,
+			"[F]Today is [C/E]gonna be [Dm7]the [Bbadd9]day",
+		},
+		{
+			"up to a sharp key",
+			"[Dm]Hello [Bb]dar[F]kness [C]my old [Dm]friend",
+			4,
+			"[F#m]Hello [D]dar[A]kness [E]my old [F#m]friend",
+		},
+		{
+			"key directive",
+			"{key: Am}\n[Am]Teplo [F]mesto [C]no [G]ulitsy",
+			-4,
+			"{key: Fm}\n[Fm]Teplo [Db]mesto [Ab]no [Eb]ulitsy",
+		},
+		{
+			"annotations and sections",
+			"{soc}\n[N.C.]Woo [*riff][A]hoo\n{eoc}",
+			5,
+			"{start_of_chorus}\n[N.C.]Woo [*riff][D]hoo\n{end_of_chorus}",
+		},
+		{
+			"full octave",
+			"[Bb]one [Eb]two",
+			12,
+			"[Bb]one [Eb]two",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet, err := ParseChordPro(tt.content)
+			assert.NoError(t, err)
+			sheet.Transpose(tt.semitones)
+			assert.Equal(t, tt.expected, sheet.String())
+		})
+	}
+}
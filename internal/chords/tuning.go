@@ -0,0 +1,182 @@
+package chords
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type Instrument string
+
+const (
+	Guitar   Instrument = "guitar"
+	Ukulele  Instrument = "ukulele"
+	Bass     Instrument = "bass"
+	Mandolin Instrument = "mandolin"
+)
+
+var Instruments = []Instrument{Guitar, Ukulele, Bass, Mandolin}
+
+// Errors of ParseInstrument and ParseTuning, wrapped with the value that
+// was not understood.
+var (
+	ErrUnknownInstrument = errors.New("unknown instrument")
+	ErrUnknownTuning     = errors.New("unknown tuning")
+	ErrInvalidTuningNote = errors.New("invalid note")
+)
+
+// Tuning names. A custom tuning has any open notes, one per string.
+const (
+	StandardTuningName = "standard"
+	DropDTuning        = "drop-d"
+	DADGADTuning       = "dadgad"
+	HalfStepDownTuning = "half-step-down"
+	CustomTuning       = "custom"
+)
+
+// Tuning lists the open string pitches as MIDI note numbers in the order
+// strings appear in a chord diagram: lowest (sixth) string first for
+// guitar, G C E A for ukulele. Mandolin courses count as one string.
+type Tuning struct {
+	Instrument Instrument
+	Name       string
+	Strings    []int
+}
+
+var (
+	StandardGuitar   = Tuning{Guitar, StandardTuningName, []int{40, 45, 50, 55, 59, 64}}
+	StandardUkulele  = Tuning{Ukulele, StandardTuningName, []int{67, 60, 64, 69}}
+	StandardBass     = Tuning{Bass, StandardTuningName, []int{28, 33, 38, 43}}
+	StandardMandolin = Tuning{Mandolin, StandardTuningName, []int{55, 62, 69, 76}}
+)
+
+// tunings are the named tunings of every instrument, standard first.
+var tunings = map[Instrument][]Tuning{
+	Guitar: {
+		StandardGuitar,
+		{Guitar, DropDTuning, []int{38, 45, 50, 55, 59, 64}},
+		{Guitar, DADGADTuning, []int{38, 45, 50, 55, 57, 62}},
+		{Guitar, HalfStepDownTuning, []int{39, 44, 49, 54, 58, 63}},
+	},
+	Ukulele: {
+		StandardUkulele,
+		{Ukulele, HalfStepDownTuning, []int{66, 59, 63, 68}},
+	},
+	Bass: {
+		StandardBass,
+		{Bass, DropDTuning, []int{26, 33, 38, 43}},
+		{Bass, HalfStepDownTuning, []int{27, 32, 37, 42}},
+	},
+	Mandolin: {
+		StandardMandolin,
+		{Mandolin, HalfStepDownTuning, []int{54, 61, 68, 75}},
+	},
+}
+
+var (
+	tuningNoteSeparators = regexp.MustCompile(`[\s,]+`)
+	compactTuningPattern = regexp.MustCompile(`^([A-G][#b]?)+$`)
+	compactTuningNote    = regexp.MustCompile(`[A-G][#b]?`)
+	tuningNotePattern    = regexp.MustCompile(`^[A-Ga-g][#b♯♭]?$`)
+)
+
+// ParseInstrument validates an instrument name.
+func ParseInstrument(s string) (Instrument, error) {
+	instrument := Instrument(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(Instruments, instrument) {
+		return "", fmt.Errorf("%w %q", ErrUnknownInstrument, s)
+	}
+	return instrument, nil
+}
+
+// StandardTuning returns the standard tuning of an instrument.
+func StandardTuning(instrument Instrument) (Tuning, error) {
+	if named, ok := tunings[instrument]; ok {
+		return named[0], nil
+	}
+	return Tuning{}, fmt.Errorf("%w %q", ErrUnknownInstrument, instrument)
+}
+
+// TuningNames lists the named tunings of an instrument, standard first.
+func TuningNames(instrument Instrument) []string {
+	names := make([]string, 0, len(tunings[instrument]))
+	for _, tuning := range tunings[instrument] {
+		names = append(names, tuning.Name)
+	}
+	return names
+}
+
+// ParseTuning reads a tuning of the instrument: empty for standard, a
+// tuning name like "drop-d" or "Drop D", or the open notes lowest string
+// first like "C G C F A D" or "CGCFAD". Notes are taken in the octave
+// closest to the standard tuning, and notes of a named tuning give that
+// tuning.
+func ParseTuning(instrument Instrument, s string) (Tuning, error) {
+	standard, err := StandardTuning(instrument)
+	if err != nil {
+		return Tuning{}, err
+	}
+
+	s = strings.TrimSpace(s)
+	name := strings.ToLower(strings.NewReplacer(" ", "-", "_", "-").Replace(s))
+	if name == "" {
+		return standard, nil
+	}
+	for _, tuning := range tunings[instrument] {
+		if tuning.Name == name {
+			return tuning, nil
+		}
+	}
+
+	notes := tuningNoteSeparators.Split(s, -1)
+	if len(notes) == 1 && compactTuningPattern.MatchString(s) {
+		notes = compactTuningNote.FindAllString(s, -1)
+	}
+	if len(notes) != len(standard.Strings) {
+		return Tuning{}, fmt.Errorf("%w %q, should be one of [%s] or the notes of the %d strings, lowest first",
+			ErrUnknownTuning, s, strings.Join(TuningNames(instrument), ", "), len(standard.Strings))
+	}
+
+	custom := Tuning{instrument, CustomTuning, make([]int, 0, len(notes))}
+	for i, note := range notes {
+		if !tuningNotePattern.MatchString(note) {
+			return Tuning{}, fmt.Errorf("%w %q for string %d of the tuning", ErrInvalidTuningNote, note, i+1)
+		}
+		pitch := pitchClass(strings.ToUpper(note[:1])[0], note[1:])
+		// The closest pitch to the standard string, down on a tie.
+		offset := mod12(pitch - standard.Strings[i])
+		if offset >= 6 {
+			offset -= 12
+		}
+		custom.Strings = append(custom.Strings, standard.Strings[i]+offset)
+	}
+
+	for _, tuning := range tunings[instrument] {
+		if slices.Equal(tuning.Strings, custom.Strings) {
+			return tuning, nil
+		}
+	}
+	return custom, nil
+}
+
+// StringNames spells the open strings of the tuning.
+func (t Tuning) StringNames() []string {
+	names := make([]string, 0, len(t.Strings))
+	for _, pitch := range t.Strings {
+		names = append(names, NoteName(pitch, false))
+	}
+	return names
+}
+
+// Notes spells the open strings the way ParseTuning reads them.
+func (t Tuning) Notes() string {
+	return strings.Join(t.StringNames(), " ")
+}
+
+// mutesStrings reports whether chords on the instrument may leave out
+// their lowest strings, which keeps the chord's bass note lowest.
+func (i Instrument) mutesStrings() bool {
+	return i == Guitar || i == Bass
+}
@@ -0,0 +1,70 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTuning(t *testing.T) {
+	tests := []struct {
+		instrument Instrument
+		tuning     string
+		name       string
+		notes      string
+	}{
+		{Guitar, "", StandardTuningName, "E A D G B E"},
+		{Guitar, "Drop D", DropDTuning, "D A D G B E"},
+		{Guitar, "DADGAD", DADGADTuning, "D A D G A D"},
+		{Guitar, "half_step_down", HalfStepDownTuning, "D# G# C# F# A# D#"},
+		{Guitar, "C G C F A D", CustomTuning, "C G C F A D"},
+		{Guitar, "CGCFAD", CustomTuning, "C G C F A D"},
+		{Guitar, "d, a, d, g, b, e", DropDTuning, "D A D G B E"},
+		{Bass, "drop-d", DropDTuning, "D A D G"},
+		{Ukulele, "G C E A", StandardTuningName, "G C E A"},
+		{Mandolin, "", StandardTuningName, "G D A E"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.instrument)+" "+tt.tuning, func(t *testing.T) {
+			tuning, err := ParseTuning(tt.instrument, tt.tuning)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.instrument, tuning.Instrument)
+			assert.Equal(t, tt.name, tuning.Name)
+			assert.Equal(t, tt.notes, tuning.Notes())
+		})
+	}
+}
+
+func TestParseTuning_Errors(t *testing.T) {
+	_, err := ParseTuning(Ukulele, "dadgad")
+	assert.EqualError(t, err, `unknown tuning "dadgad", should be one of [standard, half-step-down] or the notes of the 4 strings, lowest first`)
+	assert.ErrorIs(t, err, ErrUnknownTuning)
+
+	_, err = ParseTuning(Guitar, "D A D G B H")
+	assert.EqualError(t, err, `invalid note "H" for string 6 of the tuning`)
+	assert.ErrorIs(t, err, ErrInvalidTuningNote)
+
+	_, err = ParseTuning("banjo", "")
+	assert.EqualError(t, err, `unknown instrument "banjo"`)
+	assert.ErrorIs(t, err, ErrUnknownInstrument)
+}
+
+func TestParseTuning_TakesClosestOctave(t *testing.T) {
+	tuning, err := ParseTuning(Guitar, "C G C F A D")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{36, 43, 48, 53, 57, 62}, tuning.Strings)
+}
+
+func TestVoicings_Tunings(t *testing.T) {
+	d, _ := ParseChord("D")
+	dropD, _ := ParseTuning(Guitar, DropDTuning)
+	assert.Equal(t, []int{0, 0, 0, 2, 3, 2}, Voicings(d, dropD, 1)[0].Frets)
+
+	g, _ := ParseChord("G")
+	voicings := Voicings(g, StandardMandolin, 1)
+	assert.Equal(t, []int{0, 0, 2, 3}, voicings[0].Frets)
+
+	e, _ := ParseChord("E5")
+	assert.Equal(t, []int{0, 2, 2, 4}, Voicings(e, StandardBass, 1)[0].Frets)
+}
@@ -0,0 +1,299 @@
+package chords
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Voicing is a way to finger a chord. Frets and Fingers have one entry
+// per string in tuning order. A fret of -1 is a muted string and 0 an
+// open one; finger 0 means the string is not fretted.
+type Voicing struct {
+	Frets   []int
+	Fingers []int
+	// BaseFret is the fret the diagram starts at, 1 for open position.
+	BaseFret int
+	Barre    *Barre
+}
+
+// Barre is a fret held down by the index finger across the strings from
+// FromString to ToString, indexed like Frets.
+type Barre struct {
+	Fret       int
+	FromString int
+	ToString   int
+}
+
+const (
+	maxFret     = 12
+	handSpan    = 4
+	maxFingers  = 4
+	diagramSize = 4
+)
+
+// Voicings returns up to limit playable voicings of the chord on the
+// tuning, easiest first. Guitar and bass voicings keep the root, or the
+// bass of a slash chord, as the lowest note and only mute the lowest
+// strings.
+func Voicings(chord Chord, tuning Tuning, limit int) []Voicing {
+	required, allowed := voicingTones(chord)
+
+	candidates := make(map[string]scoredVoicing)
+	for position := 0; position <= maxFret-handSpan+1; position++ {
+		options := make([][]int, len(tuning.Strings))
+		for i, open := range tuning.Strings {
+			options[i] = stringOptions(open, position, allowed, tuning.Instrument)
+		}
+
+		frets := make([]int, len(tuning.Strings))
+		var walk func(i int)
+		walk = func(i int) {
+			if i == len(frets) {
+				if voicing, ok := checkVoicing(frets, chord, tuning, required); ok {
+					key := fmt.Sprint(frets)
+					if _, seen := candidates[key]; !seen {
+						candidates[key] = scoredVoicing{voicing, voicingScore(voicing)}
+					}
+				}
+				return
+			}
+			for _, fret := range options[i] {
+				frets[i] = fret
+				walk(i + 1)
+			}
+		}
+		walk(0)
+	}
+
+	scored := make([]scoredVoicing, 0, len(candidates))
+	for _, candidate := range candidates {
+		scored = append(scored, candidate)
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score < scored[j].score
+		}
+		return fmt.Sprint(scored[i].voicing.Frets) < fmt.Sprint(scored[j].voicing.Frets)
+	})
+
+	voicings := make([]Voicing, 0, limit)
+	for _, candidate := range scored {
+		if len(voicings) == limit {
+			break
+		}
+		voicings = append(voicings, candidate.voicing)
+	}
+	return voicings
+}
+
+type scoredVoicing struct {
+	voicing Voicing
+	score   float64
+}
+
+// voicingTones returns the intervals a voicing must contain and the
+// pitch classes it may contain. The fifth may be left out of chords with
+// a seventh or extensions, and ninths and elevenths out of thirteenths.
+func voicingTones(chord Chord) (required []int, allowed map[int]bool) {
+	intervals := chord.Intervals()
+	allowed = make(map[int]bool)
+	for _, pitch := range chord.PitchClasses() {
+		allowed[pitch] = true
+	}
+
+	optional := map[int]bool{}
+	if len(intervals) > 3 {
+		optional[7] = true
+	}
+	if len(intervals) > 5 {
+		optional[2], optional[5] = true, true
+	}
+
+	for _, interval := range intervals {
+		if !optional[interval] {
+			required = append(required, mod12(chord.Root+interval))
+		}
+	}
+	if chord.HasBass {
+		required = append(required, chord.Bass)
+	}
+	return required, allowed
+}
+
+// stringOptions lists the frets of a string that sound a chord tone
+// within the hand position, plus muting on guitar and bass.
+func stringOptions(open, position int, allowed map[int]bool, instrument Instrument) []int {
+	options := make([]int, 0, handSpan+2)
+	if instrument.mutesStrings() {
+		options = append(options, -1)
+	}
+	if allowed[mod12(open)] {
+		options = append(options, 0)
+	}
+	for fret := position; fret < position+handSpan; fret++ {
+		if fret > 0 && allowed[mod12(open+fret)] {
+			options = append(options, fret)
+		}
+	}
+	return options
+}
+
+func checkVoicing(frets []int, chord Chord, tuning Tuning, required []int) (Voicing, bool) {
+	sounding := 0
+	lowest := -1
+	minFret, maxFretted := 0, 0
+	present := make(map[int]bool)
+	for i, fret := range frets {
+		if fret < 0 {
+			// Muted strings are only allowed below the lowest sounding one.
+			if sounding > 0 {
+				return Voicing{}, false
+			}
+			continue
+		}
+		sounding++
+		pitch := tuning.Strings[i] + fret
+		present[mod12(pitch)] = true
+		if lowest < 0 || pitch < lowest {
+			lowest = pitch
+		}
+		if fret > 0 {
+			if minFret == 0 || fret < minFret {
+				minFret = fret
+			}
+			if fret > maxFretted {
+				maxFretted = fret
+			}
+		}
+	}
+
+	minStrings := len(frets) - 2
+	if chord.Quality() == Power || len(frets) < 6 {
+		minStrings = 3
+	}
+	if sounding < minStrings {
+		return Voicing{}, false
+	}
+	for _, pitch := range required {
+		if !present[pitch] {
+			return Voicing{}, false
+		}
+	}
+	if maxFretted-minFret >= handSpan {
+		return Voicing{}, false
+	}
+
+	if tuning.Instrument.mutesStrings() {
+		bass := chord.Root
+		if chord.HasBass {
+			bass = chord.Bass
+		}
+		if mod12(lowest) != bass {
+			return Voicing{}, false
+		}
+	}
+
+	voicing := Voicing{Frets: append([]int(nil), frets...), BaseFret: 1}
+	if maxFretted > diagramSize {
+		voicing.BaseFret = minFret
+	}
+	if !assignFingers(&voicing, minFret) {
+		return Voicing{}, false
+	}
+	return voicing, true
+}
+
+// assignFingers fingers the voicing, barring the lowest fret with the
+// index finger when it is used on more than one string and every string
+// under the barre is fretted at or above it. It reports false when the
+// voicing needs more than four fingers.
+func assignFingers(voicing *Voicing, minFret int) bool {
+	frets := voicing.Frets
+	voicing.Fingers = make([]int, len(frets))
+	if minFret == 0 {
+		return true
+	}
+
+	first, last := -1, -1
+	for i, fret := range frets {
+		if fret == minFret {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+
+	barre := first != last
+	for i := first; barre && i <= last; i++ {
+		if frets[i] < minFret {
+			barre = false
+		}
+	}
+
+	type note struct{ string, fret int }
+	notes := make([]note, 0, len(frets))
+	finger := 1
+	for i, fret := range frets {
+		switch {
+		case fret <= 0:
+		case barre && fret == minFret:
+			voicing.Fingers[i] = 1
+		default:
+			notes = append(notes, note{i, fret})
+		}
+	}
+	if barre {
+		voicing.Barre = &Barre{minFret, first, last}
+		finger = 2
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].fret < notes[j].fret })
+	for _, n := range notes {
+		if finger > maxFingers {
+			return false
+		}
+		voicing.Fingers[n.string] = finger
+		finger++
+	}
+	return true
+}
+
+// voicingScore is lower for voicings that are easier to play: low on the
+// neck, with few fingers, open strings and all strings sounding.
+func voicingScore(voicing Voicing) float64 {
+	var score float64
+	fingers := 0
+	for i, fret := range voicing.Frets {
+		switch {
+		case fret < 0:
+			score += 1.5
+		case fret == 0:
+			score -= 0.5
+		default:
+			if voicing.Fingers[i] > fingers {
+				fingers = voicing.Fingers[i]
+			}
+		}
+	}
+	score += float64(fingers)
+	if voicing.Barre != nil {
+		score += 1
+	}
+	if voicing.BaseFret > 1 {
+		score += float64(voicing.BaseFret)
+	}
+
+	minFret, maxFretted := 0, 0
+	for _, fret := range voicing.Frets {
+		if fret > 0 && (minFret == 0 || fret < minFret) {
+			minFret = fret
+		}
+		if fret > maxFretted {
+			maxFretted = fret
+		}
+	}
+	score += float64(maxFretted-minFret) * 0.5
+	score += float64(minFret) * 0.5
+	return score
+}
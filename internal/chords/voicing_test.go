@@ -0,0 +1,94 @@
+package chords
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVoicings_OpenShapesFirst(t *testing.T) {
+	tests := []struct {
+		symbol string
+		tuning Tuning
+		frets  []int
+	}{
+		{"C", StandardGuitar, []int{-1, 3, 2, 0, 1, 0}},
+		{"G", StandardGuitar, []int{3, 2, 0, 0, 0, 3}},
+		{"Am", StandardGuitar, []int{-1, 0, 2, 2, 1, 0}},
+		{"E", StandardGuitar, []int{0, 2, 2, 1, 0, 0}},
+		{"Cmaj7", StandardGuitar, []int{-1, 3, 2, 0, 0, 0}},
+		{"C", StandardUkulele, []int{0, 0, 0, 3}},
+		{"Am", StandardUkulele, []int{2, 0, 0, 0}},
+		{"F", StandardUkulele, []int{2, 0, 1, 0}},
+	}
+
+	for _, tt := range tests {
+		chord, err := ParseChord(tt.symbol)
+		assert.NoError(t, err)
+
+		voicings := Voicings(chord, tt.tuning, 3)
+		if assert.NotEmpty(t, voicings, tt.symbol) {
+			assert.Equal(t, tt.frets, voicings[0].Frets, "%s on %s", tt.symbol, tt.tuning.Instrument)
+		}
+	}
+}
+
+func TestVoicings_Barre(t *testing.T) {
+	chord, _ := ParseChord("F")
+
+	voicing := Voicings(chord, StandardGuitar, 1)[0]
+	assert.Equal(t, []int{1, 3, 3, 2, 1, 1}, voicing.Frets)
+	assert.Equal(t, []int{1, 3, 4, 2, 1, 1}, voicing.Fingers)
+	assert.Equal(t, &Barre{Fret: 1, FromString: 0, ToString: 5}, voicing.Barre)
+	assert.Equal(t, 1, voicing.BaseFret)
+}
+
+func TestVoicings_BassIsLowestNote(t *testing.T) {
+	for _, symbol := range []string{"G/B", "D/F#", "Cmaj7/G", "Bm", "Ebdim7"} {
+		chord, _ := ParseChord(symbol)
+		bass := chord.Root
+		if chord.HasBass {
+			bass = chord.Bass
+		}
+
+		voicings := Voicings(chord, StandardGuitar, 3)
+		assert.NotEmpty(t, voicings, symbol)
+		for _, voicing := range voicings {
+			lowest := -1
+			for i, fret := range voicing.Frets {
+				if fret >= 0 {
+					lowest = StandardGuitar.Strings[i] + fret
+					break
+				}
+			}
+			assert.Equal(t, bass, mod12(lowest), "%s %v", symbol, voicing.Frets)
+		}
+	}
+}
+
+func TestVoicings_HighPositionBaseFret(t *testing.T) {
+	chord, _ := ParseChord("C#m")
+
+	for _, voicing := range Voicings(chord, StandardGuitar, 5) {
+		maxFretted := 0
+		for _, fret := range voicing.Frets {
+			maxFretted = max(maxFretted, fret)
+		}
+		if maxFretted > 4 {
+			assert.Greater(t, voicing.BaseFret, 1, "%v", voicing.Frets)
+		}
+	}
+}
+
+func TestStandardTuning(t *testing.T) {
+	tuning, err := StandardTuning(Guitar)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"E", "A", "D", "G", "B", "E"}, tuning.StringNames())
+
+	tuning, err = StandardTuning(Ukulele)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"G", "C", "E", "A"}, tuning.StringNames())
+
+	_, err = StandardTuning("banjo")
+	assert.Error(t, err)
+}
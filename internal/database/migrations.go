@@ -9,5 +9,6 @@ import (
 func AutoMigrate(db *gorm.DB) {
 	db.AutoMigrate(
 		&models.User{}, &models.Song{}, &models.Artist{}, &models.SongArtist{}, &models.SongRequest{},
+		&models.Tag{}, &models.SearchOutbox{}, &models.ImportJob{}, &models.ImportJobFile{},
 	)
 }
@@ -0,0 +1,103 @@
+// Package diagrams draws chord diagrams.
+package diagrams
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+
+	"chords_app/internal/chords"
+)
+
+const (
+	stringSpacing = 20
+	fretSpacing   = 24
+	minFrets      = 5
+	marginLeft    = 30
+	marginRight   = 20
+	// marginTop leaves room for the chord name and the open and muted
+	// string markers above the nut.
+	marginTop    = 50
+	marginBottom = 24
+	dotRadius    = 8
+)
+
+// SVG draws the voicing as a vertical fretboard: strings left to right in
+// tuning order, the nut or the starting fret at the top, and the open
+// string names at the bottom. The output only depends on its arguments.
+func SVG(name string, voicing chords.Voicing, tuning chords.Tuning) []byte {
+	strings := len(tuning.Strings)
+	frets := minFrets
+	for _, fret := range voicing.Frets {
+		if fret > 0 {
+			frets = max(frets, fret-voicing.BaseFret+1)
+		}
+	}
+
+	width := marginLeft + (strings-1)*stringSpacing + marginRight
+	height := marginTop + frets*fretSpacing + marginBottom
+	right := marginLeft + (strings-1)*stringSpacing
+	bottom := marginTop + frets*fretSpacing
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`, width, height, width, height)
+	fmt.Fprintf(&b, `<title>%s</title>`, html.EscapeString(name))
+	fmt.Fprintf(&b, `<text x="%d" y="18" font-size="16" text-anchor="middle">%s</text>`, (marginLeft+right)/2, html.EscapeString(name))
+
+	if voicing.BaseFret <= 1 {
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black" stroke-width="4"/>`, marginLeft, marginTop, right, marginTop)
+	} else {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="12" text-anchor="end">%dfr</text>`, marginLeft-10, marginTop+fretSpacing/2+4, voicing.BaseFret)
+	}
+	for fret := 0; fret <= frets; fret++ {
+		y := marginTop + fret*fretSpacing
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`, marginLeft, y, right, y)
+	}
+	for i := 0; i < strings; i++ {
+		x := stringX(i)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`, x, marginTop, x, bottom)
+	}
+
+	barreFret := 0
+	if barre := voicing.Barre; barre != nil {
+		barreFret = barre.Fret
+		y := fretY(barre.Fret, voicing.BaseFret)
+		x1, x2 := stringX(barre.FromString), stringX(barre.ToString)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="%d" fill="black"/>`, x1-dotRadius, y-dotRadius, x2-x1+2*dotRadius, 2*dotRadius, dotRadius)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" text-anchor="middle" fill="white">1</text>`, (x1+x2)/2, y+4)
+	}
+
+	for i, fret := range voicing.Frets {
+		x := stringX(i)
+		switch {
+		case fret < 0:
+			fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="14" text-anchor="middle">×</text>`, x, marginTop-8)
+		case fret == 0:
+			fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="5" fill="none" stroke="black"/>`, x, marginTop-12)
+		case fret == barreFret && voicing.Fingers[i] == 1:
+		default:
+			y := fretY(fret, voicing.BaseFret)
+			fmt.Fprintf(&b, `<circle cx="%d" cy="%d" r="%d" fill="black"/>`, x, y, dotRadius)
+			if finger := voicing.Fingers[i]; finger > 0 {
+				fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" text-anchor="middle" fill="white">%d</text>`, x, y+4, finger)
+			}
+		}
+	}
+
+	for i, note := range tuning.StringNames() {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="11" text-anchor="middle">%s</text>`, stringX(i), bottom+16, note)
+	}
+
+	b.WriteString(`</svg>`)
+	return b.Bytes()
+}
+
+func stringX(i int) int {
+	return marginLeft + i*stringSpacing
+}
+
+// fretY is the vertical center of the space a fret is pressed in.
+func fretY(fret, baseFret int) int {
+	row := fret - max(baseFret, 1)
+	return marginTop + row*fretSpacing + fretSpacing/2
+}
@@ -0,0 +1,57 @@
+package diagrams
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"chords_app/internal/chords"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSVG_OpenChord(t *testing.T) {
+	voicing := chords.Voicing{
+		Frets:    []int{-1, 0, 2, 2, 1, 0},
+		Fingers:  []int{0, 0, 2, 3, 1, 0},
+		BaseFret: 1,
+	}
+
+	svg := string(SVG("Am", voicing, chords.StandardGuitar))
+
+	assert.NoError(t, xml.Unmarshal([]byte(svg), new(struct{})), "expected well-formed XML")
+	assert.Contains(t, svg, `<title>Am</title>`)
+	assert.Contains(t, svg, `stroke-width="4"`, "expected the nut to be drawn")
+	assert.Equal(t, 1, strings.Count(svg, "×"), "expected one muted string")
+	assert.Equal(t, 2, strings.Count(svg, `fill="none"`), "expected two open strings")
+	assert.Equal(t, 3, strings.Count(svg, `r="8"`), "expected three fretted notes")
+	assert.NotContains(t, svg, "fr</text>")
+}
+
+func TestSVG_BarreAtBaseFret(t *testing.T) {
+	voicing := chords.Voicing{
+		Frets:    []int{-1, 4, 6, 6, 5, 4},
+		Fingers:  []int{0, 1, 3, 4, 2, 1},
+		BaseFret: 4,
+		Barre:    &chords.Barre{Fret: 4, FromString: 1, ToString: 5},
+	}
+
+	svg := string(SVG("C#m", voicing, chords.StandardGuitar))
+
+	assert.Contains(t, svg, ">4fr</text>")
+	assert.NotContains(t, svg, `stroke-width="4"`, "expected no nut above the fourth fret")
+	assert.Equal(t, 1, strings.Count(svg, "<rect"))
+	assert.Equal(t, 3, strings.Count(svg, `r="8"`), "expected barred strings to be covered by the barre")
+	assert.Equal(t, string(SVG("C#m", voicing, chords.StandardGuitar)), svg, "expected deterministic output")
+}
+
+func TestSVG_EscapesName(t *testing.T) {
+	voicing := chords.Voicing{Frets: []int{0, 0, 0, 3}, Fingers: []int{0, 0, 0, 3}, BaseFret: 1}
+
+	svg := string(SVG("<C>", voicing, chords.StandardUkulele))
+
+	assert.Contains(t, svg, "&lt;C&gt;")
+	for _, note := range []string{">G</text>", ">C</text>", ">E</text>", ">A</text>"} {
+		assert.Contains(t, svg, note, "expected open string names")
+	}
+}
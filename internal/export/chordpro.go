@@ -0,0 +1,41 @@
+package export
+
+import (
+	"strconv"
+
+	"chords_app/internal/chords"
+)
+
+// ChordPro writes the song back to ChordPro with its title, artists,
+// capo, instrument and tuning as directives at the top, replacing the ones
+// in the content.
+func ChordPro(song Song) []byte {
+	sheet := song.Sheet.Clone()
+	for _, name := range []string{"title", "artist", "capo", "instrument", "tuning"} {
+		sheet.RemoveDirective(name)
+	}
+
+	header := []chords.Line{directiveLine("title", song.Title)}
+	for _, artist := range song.Artists {
+		header = append(header, directiveLine("artist", artist))
+	}
+	if song.Capo > 0 {
+		header = append(header, directiveLine("capo", strconv.FormatUint(uint64(song.Capo), 10)))
+	}
+	if song.Instrument != "" {
+		header = append(header, directiveLine("instrument", song.Instrument))
+	}
+	if song.Tuning != "" {
+		header = append(header, directiveLine("tuning", song.Tuning))
+	}
+
+	if len(sheet.Sections) == 0 || sheet.Sections[0].Kind != chords.SectionNone {
+		sheet.Sections = append([]chords.Section{{Kind: chords.SectionNone}}, sheet.Sections...)
+	}
+	sheet.Sections[0].Lines = append(header, sheet.Sections[0].Lines...)
+	return []byte(sheet.String() + "\n")
+}
+
+func directiveLine(name, value string) chords.Line {
+	return chords.Line{Kind: chords.DirectiveLine, Directive: chords.Directive{Name: name, Value: value}}
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"chords_app/internal/translit"
+)
+
+type Format string
+
+const (
+	FormatPDF      Format = "pdf"
+	FormatChordPro Format = "chordpro"
+	FormatText     Format = "text"
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+)
+
+var Formats = []Format{FormatPDF, FormatChordPro, FormatText, FormatHTML, FormatMarkdown}
+
+// File is an exported song document.
+type File struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+func ParseFormat(s string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == s {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("invalid format %q", s)
+}
+
+// Render exports the song in the format.
+func Render(song Song, format Format) (*File, error) {
+	switch format {
+	case FormatPDF:
+		return &File{Filename(song.Title, "pdf"), "application/pdf", PDF(song)}, nil
+	case FormatChordPro:
+		return &File{Filename(song.Title, "cho"), "text/plain; charset=utf-8", ChordPro(song)}, nil
+	case FormatText:
+		return &File{Filename(song.Title, "txt"), "text/plain; charset=utf-8", Text(song)}, nil
+	case FormatHTML:
+		return &File{Filename(song.Title, "html"), "text/html; charset=utf-8", HTML(song)}, nil
+	case FormatMarkdown:
+		return &File{Filename(song.Title, "md"), "text/markdown; charset=utf-8", Markdown(song)}, nil
+	}
+	return nil, fmt.Errorf("invalid format %q", format)
+}
+
+// Filename makes a file name from the song title: transliterated,
+// lowercase, with runs of other characters turned into dashes.
+func Filename(title, extension string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(translit.ToLatin(title)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("song")
+	}
+	return b.String() + "." + extension
+}
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+const goldenContent = `{title: Wonderwall}
+{artist: Oasis}
+# capo on the 2nd fret
+{comment: Intro}
+[Em7]Today is [G]gonna be the day
+That they're [Dsus4]gonna throw it back to [A7sus4]you
+
+{start_of_chorus: Chorus}
+And [C]all the roads we [D]have to walk are [Em]winding[*!]
+Because [C]maybe, you're [Em]gonna be the [G]one that *saves* me
+{end_of_chorus}
+{chorus}
+[N.C.]I said maybe[C]
+
+{start_of_tab: Riff}
+e|--3--3--|
+B|--3--3--|
+{end_of_tab}`
+
+func TestRender_GoldenFiles(t *testing.T) {
+	song := Song{
+		Title:   "Wonderwall",
+		Artists: []string{"Oasis", "Noel Gallagher"},
+		Key:     "F#m",
+		Capo:    2,
+		Sheet:   parseSheet(t, goldenContent),
+	}
+
+	for _, format := range Formats {
+		file, err := Render(song, format)
+		assert.NoError(t, err)
+
+		golden := filepath.Join("testdata", file.Filename+".golden")
+		if *update {
+			if err := os.WriteFile(golden, file.Data, 0o644); err != nil {
+				t.Fatalf("failed to update golden file: %v", err)
+			}
+		}
+		expected, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("failed to read golden file, run tests with -update to create it: %v", err)
+		}
+		assert.Equal(t, string(expected), string(file.Data), "format %s", format)
+	}
+}
+
+func TestRender_ChordProRoundTrip(t *testing.T) {
+	song := Song{Title: "Wonderwall", Artists: []string{"Oasis"}, Sheet: parseSheet(t, goldenContent)}
+
+	exported := parseSheet(t, string(ChordPro(song)))
+	assert.Equal(t, "Wonderwall", exported.Title())
+	assert.Equal(t, song.Sheet.Chords(), exported.Chords())
+	assert.Empty(t, exported.DirectiveValue("capo"))
+}
+
+func TestRender_InvalidFormat(t *testing.T) {
+	_, err := Render(Song{Sheet: parseSheet(t, "")}, "docx")
+	assert.Error(t, err)
+
+	_, err = ParseFormat("docx")
+	assert.Error(t, err)
+}
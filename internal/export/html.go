@@ -0,0 +1,53 @@
+package export
+
+import (
+	"html"
+	"strings"
+)
+
+const htmlStyle = `body { font-family: sans-serif; margin: 2em; }
+h1 { margin-bottom: 0.2em; }
+.details { color: #555; margin: 0.2em 0; }
+section { margin: 1.2em 0; break-inside: avoid; }
+h2 { font-size: 1em; margin: 0 0 0.3em; }
+.chords, .lyrics { font-family: monospace; white-space: pre; margin: 0; }
+.chords { color: #c0392b; font-weight: bold; }
+.comment { font-style: italic; color: #555; margin: 0.3em 0; }
+`
+
+// HTML writes the song as a standalone page with its own styles. Chords
+// stay over the lyrics in a monospaced font and sections are not split
+// when printed.
+func HTML(song Song) []byte {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>" + html.EscapeString(song.Title) + "</title>\n")
+	b.WriteString("<style>\n" + htmlStyle + "</style>\n</head>\n<body>\n")
+	b.WriteString("<h1>" + html.EscapeString(song.Title) + "</h1>\n")
+	for _, line := range song.details() {
+		b.WriteString(`<p class="details">` + html.EscapeString(line) + "</p>\n")
+	}
+
+	for _, block := range layoutBlocks(song.Sheet) {
+		b.WriteString("<section>\n")
+		for _, u := range block.units {
+			for _, r := range u {
+				text := html.EscapeString(r.text)
+				switch r.style {
+				case labelRow:
+					b.WriteString("<h2>" + text + "</h2>\n")
+				case commentRow:
+					b.WriteString(`<p class="comment">` + text + "</p>\n")
+				case chordsRow:
+					b.WriteString(`<div class="chords">` + text + "</div>\n")
+				default:
+					b.WriteString(`<div class="lyrics">` + text + "</div>\n")
+				}
+			}
+		}
+		b.WriteString("</section>\n")
+	}
+
+	b.WriteString("</body>\n</html>\n")
+	return []byte(b.String())
+}
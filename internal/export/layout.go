@@ -0,0 +1,166 @@
+// Package export renders songs into documents for printing and sharing.
+package export
+
+import (
+	"strconv"
+	"strings"
+
+	"chords_app/internal/chords"
+)
+
+// Song is what an exported document shows. Artists are in title order.
+type Song struct {
+	Title   string
+	Artists []string
+	Key     string
+	Capo    uint
+	// Instrument is empty for guitar and Tuning, the open strings lowest
+	// first, empty for the instrument's standard tuning.
+	Instrument string
+	Tuning     string
+	Sheet      *chords.Sheet
+}
+
+type rowStyle int
+
+const (
+	lyricsRow rowStyle = iota
+	chordsRow
+	labelRow
+	commentRow
+)
+
+type row struct {
+	style rowStyle
+	text  string
+}
+
+// unit is a group of rows that is never split, like a chord line and the
+// lyrics under it.
+type unit []row
+
+// block is a section or a paragraph of the song. Layouts keep blocks on
+// one page when they fit on one.
+type block struct {
+	units []unit
+	// pageBreak starts the block on a new page, as {new_page} asks.
+	pageBreak bool
+}
+
+// commentDirectives are shown as comment rows, other directives describe
+// the song and are left out of the body.
+var commentDirectives = map[string]bool{
+	"comment": true, "comment_italic": true, "comment_box": true, "highlight": true,
+}
+
+// layoutBlocks splits the sheet into blocks: one per environment, and one
+// per paragraph of the lines outside environments. Tab lines are kept as
+// written in the lyrics font, which is monospaced.
+func layoutBlocks(sheet *chords.Sheet) []block {
+	blocks := make([]block, 0)
+	pageBreak := false
+	var current block
+
+	flush := func() {
+		if len(current.units) > 0 {
+			current.pageBreak = pageBreak
+			blocks = append(blocks, current)
+			pageBreak = false
+		}
+		current = block{}
+	}
+
+	for _, section := range sheet.Sections {
+		if section.Kind != chords.SectionNone {
+			flush()
+			current.units = append(current.units, unit{{labelRow, sectionLabel(section)}})
+		}
+
+		for j, line := range section.Lines {
+			switch line.Kind {
+			case chords.EmptyLine:
+				if section.Kind == chords.SectionNone {
+					flush()
+				}
+			case chords.LyricsLine:
+				chordLine, lyricLine := line.ChordsOverLyrics()
+				pair := make(unit, 0, 2)
+				if chordLine != "" {
+					pair = append(pair, row{chordsRow, chordLine})
+				}
+				if lyricLine != "" || chordLine == "" {
+					pair = append(pair, row{lyricsRow, lyricLine})
+				}
+				current.units = append(current.units, pair)
+			case chords.TabLine:
+				// The lines of a staff are one unit so its strings are
+				// never split across pages.
+				if j > 0 && section.Lines[j-1].Kind == chords.TabLine {
+					last := &current.units[len(current.units)-1]
+					*last = append(*last, row{lyricsRow, line.Text})
+				} else {
+					current.units = append(current.units, unit{{lyricsRow, line.Text}})
+				}
+			case chords.DirectiveLine:
+				switch name := line.Directive.Name; {
+				case commentDirectives[name]:
+					current.units = append(current.units, unit{{commentRow, line.Directive.Value}})
+				case name == "chorus":
+					// {chorus} asks to play the chorus again, it stands on
+					// its own between paragraphs.
+					label := line.Directive.Value
+					if label == "" {
+						label = "Chorus"
+					}
+					flush()
+					current.units = append(current.units, unit{{commentRow, label}})
+					flush()
+				case name == "new_page" || name == "new_physical_page":
+					flush()
+					pageBreak = true
+				}
+			}
+		}
+
+		if section.Kind != chords.SectionNone {
+			flush()
+		}
+	}
+	flush()
+	return blocks
+}
+
+func sectionLabel(section chords.Section) string {
+	if section.Label != "" {
+		return section.Label
+	}
+	kind := string(section.Kind)
+	return strings.ToUpper(kind[:1]) + kind[1:]
+}
+
+// details are the lines under the title: the artists, then key, capo,
+// instrument and tuning.
+func (s Song) details() []string {
+	details := make([]string, 0, 2)
+	if len(s.Artists) > 0 {
+		details = append(details, strings.Join(s.Artists, ", "))
+	}
+
+	meta := make([]string, 0, 2)
+	if s.Key != "" {
+		meta = append(meta, "Key: "+s.Key)
+	}
+	if s.Capo > 0 {
+		meta = append(meta, "Capo: "+strconv.FormatUint(uint64(s.Capo), 10))
+	}
+	if s.Instrument != "" {
+		meta = append(meta, "Instrument: "+s.Instrument)
+	}
+	if s.Tuning != "" {
+		meta = append(meta, "Tuning: "+s.Tuning)
+	}
+	if len(meta) > 0 {
+		details = append(details, strings.Join(meta, "   "))
+	}
+	return details
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "|", `\|`,
+)
+
+// markdownText escapes text so it is not read as Markdown markup.
+func markdownText(s string) string {
+	s = markdownEscaper.Replace(s)
+	if strings.HasPrefix(s, "#") {
+		s = `\` + s
+	}
+	return s
+}
+
+// Markdown writes the song with chords over lyrics in code blocks, which
+// keep them aligned, and section labels as headings.
+func Markdown(song Song) []byte {
+	var b strings.Builder
+	b.WriteString("# " + markdownText(song.Title) + "\n")
+	for _, line := range song.details() {
+		b.WriteString("\n" + markdownText(line) + "\n")
+	}
+
+	for _, block := range layoutBlocks(song.Sheet) {
+		b.WriteString("\n")
+		fenced := false
+		fence := func(open bool) {
+			if fenced != open {
+				b.WriteString("```\n")
+				fenced = open
+			}
+		}
+
+		for _, u := range block.units {
+			for _, r := range u {
+				switch r.style {
+				case labelRow:
+					fence(false)
+					b.WriteString("## " + markdownText(r.text) + "\n\n")
+				case commentRow:
+					fence(false)
+					b.WriteString("*" + markdownText(r.text) + "*\n\n")
+				default:
+					fence(true)
+					b.WriteString(r.text + "\n")
+				}
+			}
+		}
+		fence(false)
+	}
+	return []byte(strings.TrimRight(b.String(), "\n") + "\n")
+}
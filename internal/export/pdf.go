@@ -0,0 +1,393 @@
+package export
+
+import (
+	"bytes"
+	"compress/zlib"
+	"embed"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 page in points.
+const (
+	pageWidth  = 595
+	pageHeight = 842
+	pageMargin = 56
+
+	titleSize   = 18
+	detailsSize = 11
+	bodySize    = 10
+	footerSize  = 9
+	lineHeight  = 12
+	// bodyWidth and bodyHeight are the size of the page between the
+	// margins.
+	bodyWidth  = pageWidth - 2*pageMargin
+	bodyHeight = pageHeight - 2*pageMargin
+	// columns is how many monospaced characters fit between the margins;
+	// DejaVu Sans Mono glyphs are 0.6 em wide.
+	columns = bodyWidth * 10 / (bodySize * 6)
+)
+
+//go:embed fonts/*.ttf
+var fontFiles embed.FS
+
+func mustLoadFont(name string) *trueTypeFont {
+	data, err := fontFiles.ReadFile("fonts/" + name + ".ttf")
+	if err != nil {
+		panic(err)
+	}
+	return mustParseTrueType(name, data)
+}
+
+// The PDF embeds subsets of the DejaVu fonts, which cover Cyrillic, and
+// writes text as glyph IDs with a ToUnicode map so it can be copied and
+// searched. Comments are set in the lyrics font slanted.
+var pdfFonts = []*trueTypeFont{
+	mustLoadFont("DejaVuSansMono"),
+	mustLoadFont("DejaVuSansMono-Bold"),
+	mustLoadFont("DejaVuSans"),
+	mustLoadFont("DejaVuSans-Bold"),
+}
+
+const (
+	fontLyrics   = "F1"
+	fontChords   = "F2"
+	fontDetails  = "F3"
+	fontHeadings = "F4"
+)
+
+// obliqueMatrix slants text by about 11 degrees.
+const obliqueMatrix = "1 0 0.2 1"
+
+var rowFonts = map[rowStyle]string{
+	lyricsRow:  fontLyrics,
+	chordsRow:  fontChords,
+	commentRow: fontLyrics,
+	labelRow:   fontHeadings,
+}
+
+// PDF lays the song out on A4 pages: the title, artists, key and capo,
+// then the chords over the lyrics in a monospaced font. A section or a
+// paragraph that does not fit on the rest of a page starts a new one, so
+// only sections longer than a page are split, and only a staff or a line
+// longer than a page is split across pages.
+func PDF(song Song) []byte {
+	layout := &pdfLayout{}
+	layout.newPage()
+
+	for _, line := range wrapText(fontHeadings, titleSize, song.Title) {
+		layout.text(fontHeadings, titleSize, pageMargin, line)
+	}
+	layout.y -= titleSize / 2
+	for _, details := range song.details() {
+		for _, line := range wrapText(fontDetails, detailsSize, details) {
+			layout.text(fontDetails, detailsSize, pageMargin, line)
+		}
+	}
+	layout.y -= lineHeight
+
+	for _, b := range layoutBlocks(song.Sheet) {
+		units := make([]unit, 0, len(b.units))
+		height := 0
+		for _, u := range b.units {
+			u = wrapUnit(u, columns)
+			units = append(units, u)
+			height += len(u) * lineHeight
+		}
+
+		fitsOnPage := height <= bodyHeight
+		if !layout.atTop() && (b.pageBreak || (height > layout.remaining() && fitsOnPage)) {
+			layout.newPage()
+		}
+		for _, u := range units {
+			if height := len(u) * lineHeight; height > layout.remaining() && height <= bodyHeight {
+				layout.newPage()
+			}
+			for _, r := range u {
+				if lineHeight > layout.remaining() {
+					layout.newPage()
+				}
+				if r.style == commentRow {
+					layout.obliqueText(rowFonts[r.style], bodySize, pageMargin, r.text)
+				} else {
+					layout.text(rowFonts[r.style], bodySize, pageMargin, r.text)
+				}
+			}
+		}
+		layout.y -= lineHeight
+	}
+
+	return layout.document()
+}
+
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	y     int
+	// glyphs has the characters drawn with every font by glyph ID.
+	glyphs map[string]map[uint16]rune
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, new(bytes.Buffer))
+	l.y = pageHeight - pageMargin
+}
+
+func (l *pdfLayout) atTop() bool {
+	return l.y == pageHeight-pageMargin
+}
+
+func (l *pdfLayout) remaining() int {
+	return l.y - pageMargin
+}
+
+// text writes a line below the previous one.
+func (l *pdfLayout) text(font string, size, x int, s string) {
+	l.y -= max(size+size/5, lineHeight)
+	l.show(font, size, fmt.Sprintf("%d %d Td", x, l.y), s)
+}
+
+// obliqueText writes a slanted line below the previous one.
+func (l *pdfLayout) obliqueText(font string, size, x int, s string) {
+	l.y -= max(size+size/5, lineHeight)
+	l.show(font, size, fmt.Sprintf("%s %d %d Tm", obliqueMatrix, x, l.y), s)
+}
+
+func (l *pdfLayout) show(font string, size int, position, s string) {
+	page := l.pages[len(l.pages)-1]
+	fmt.Fprintf(page, "BT /%s %d Tf %s %s Tj ET\n", font, size, position, l.encode(font, s))
+}
+
+// encode writes text as a hex string of the glyph IDs in the font.
+// Characters the font lacks are replaced with ?.
+func (l *pdfLayout) encode(font, s string) string {
+	if l.glyphs == nil {
+		l.glyphs = make(map[string]map[uint16]rune)
+	}
+	if l.glyphs[font] == nil {
+		l.glyphs[font] = make(map[uint16]rune)
+	}
+	glyphs := pdfFont(font).glyphs
+
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		if r == '\t' {
+			r = ' '
+		}
+		glyph, ok := glyphs[r]
+		if !ok || r < 0x20 {
+			r, glyph = '?', glyphs['?']
+		}
+		l.glyphs[font][glyph] = r
+		fmt.Fprintf(&b, "%04X", glyph)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+func pdfFont(name string) *trueTypeFont {
+	i, _ := strconv.Atoi(strings.TrimPrefix(name, "F"))
+	return pdfFonts[i-1]
+}
+
+// document numbers the pages and writes the PDF file. The output only
+// depends on the layout, there are no dates or IDs in it.
+func (l *pdfLayout) document() []byte {
+	for i := range l.pages {
+		if len(l.pages) > 1 {
+			fmt.Fprintf(l.pages[i], "BT /%s %d Tf %d %d Td %s Tj ET\n", fontDetails, footerSize,
+				pageWidth-pageMargin-40, pageMargin/2, l.encode(fontDetails, fmt.Sprintf("%d / %d", i+1, len(l.pages))))
+		}
+	}
+
+	var b bytes.Buffer
+	offsets := make([]int, 0)
+	object := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects: catalog, page tree, five objects for every font, then a
+	// page and its content stream for every page.
+	firstPage := 3 + fontObjects*len(pdfFonts)
+	kids := make([]string, 0, len(l.pages))
+	for i := range l.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	fonts := make([]string, 0, len(pdfFonts))
+	for i := range pdfFonts {
+		fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i+1, 3+fontObjects*i))
+	}
+
+	b.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(l.pages)))
+	for i, font := range pdfFonts {
+		for _, body := range fontObjectBodies(font, l.glyphs[fmt.Sprintf("F%d", i+1)], 3+fontObjects*i) {
+			object(body)
+		}
+	}
+	for i, page := range l.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, strings.Join(fonts, " "), firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return b.Bytes()
+}
+
+// fontObjects is the number of objects fontObjectBodies returns.
+const fontObjects = 5
+
+// fontObjectBodies returns the objects of a composite font starting at
+// object first: the Type0 font, its CIDFont, the font descriptor, the
+// subset font file and the ToUnicode map. CIDs are the glyph IDs.
+func fontObjectBodies(font *trueTypeFont, glyphs map[uint16]rune, first int) []string {
+	ids := make([]uint16, 0, len(glyphs))
+	used := make(map[uint16]bool, len(glyphs))
+	for id := range glyphs {
+		ids = append(ids, id)
+		used[id] = true
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	name := subsetTag(ids) + "+" + font.name
+	widths := make([]string, 0, len(ids))
+	for _, id := range ids {
+		widths = append(widths, fmt.Sprintf("%d [%d]", id, font.scale(int(font.widths[id]))))
+	}
+	flags := 32
+	if font.fixedPitch {
+		flags |= 1
+	}
+
+	return []string{
+		fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+			name, first+1, first+4),
+		fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /DW %d /W [%s] /CIDToGIDMap /Identity >>",
+			name, first+2, font.scale(int(font.widths[0])), strings.Join(widths, " ")),
+		fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags %d /FontBBox [%d %d %d %d] /ItalicAngle 0 "+
+			"/Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+			name, flags, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]), font.scale(font.bbox[3]),
+			font.scale(font.ascent), font.scale(font.descent), font.scale(font.capHeight), first+3),
+		fontFileStream(font.subset(used)),
+		toUnicodeStream(ids, glyphs),
+	}
+}
+
+func fontFileStream(data []byte) string {
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	w.Write(data)
+	w.Close()
+	return fmt.Sprintf("<< /Length %d /Length1 %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+		compressed.Len(), len(data), compressed.String())
+}
+
+// toUnicodeStream maps glyph IDs back to the characters they were drawn
+// for, so text copied from the PDF is not a list of glyph IDs.
+func toUnicodeStream(ids []uint16, glyphs map[uint16]rune) string {
+	var b strings.Builder
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	// A bfchar block holds at most 100 entries.
+	for start := 0; start < len(ids); start += 100 {
+		chunk := ids[start:min(start+100, len(ids))]
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, id := range chunk {
+			fmt.Fprintf(&b, "<%04X> <", id)
+			for _, unit := range utf16.Encode([]rune{glyphs[id]}) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">\n")
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", b.Len(), b.String())
+}
+
+// subsetTag names a font subset with six capital letters derived from
+// the glyphs in it, as the PDF spec asks, keeping the output deterministic.
+func subsetTag(ids []uint16) string {
+	h := fnv.New32a()
+	for _, id := range ids {
+		h.Write([]byte{byte(id >> 8), byte(id)})
+	}
+	sum := h.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+	return string(tag)
+}
+
+// wrapText breaks text set in the font at spaces so every line fits
+// between the margins. Words wider than that are broken anywhere.
+func wrapText(font string, size int, s string) []string {
+	ttf := pdfFont(font)
+	limit := bodyWidth * ttf.unitsPerEm / size
+
+	lines := make([]string, 0, 1)
+	text := []rune(strings.TrimSpace(s))
+	for len(text) > 0 {
+		end, width, lastSpace := 0, 0, 0
+		for ; end < len(text); end++ {
+			advance := ttf.advance(text[end])
+			if end > 0 && width+advance > limit {
+				break
+			}
+			if text[end] == ' ' {
+				lastSpace = end
+			}
+			width += advance
+		}
+		if end < len(text) && lastSpace > 0 {
+			end = lastSpace
+		}
+		lines = append(lines, strings.TrimRight(string(text[:end]), " "))
+		text = []rune(strings.TrimLeft(string(text[end:]), " "))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// wrapUnit breaks rows longer than the page is wide. A chord line and its
+// lyrics are broken at the same column so chords stay above their words.
+func wrapUnit(u unit, width int) unit {
+	longest := 0
+	for _, r := range u {
+		longest = max(longest, len([]rune(r.text)))
+	}
+	if longest <= width {
+		return u
+	}
+
+	wrapped := make(unit, 0, len(u)*(longest/width+1))
+	for start := 0; start < longest; start += width {
+		for _, r := range u {
+			text := []rune(r.text)
+			if start >= len(text) {
+				continue
+			}
+			wrapped = append(wrapped, row{r.style, strings.TrimRight(string(text[start:min(start+width, len(text))]), " ")})
+		}
+	}
+	return wrapped
+}
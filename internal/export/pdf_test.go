@@ -0,0 +1,232 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"chords_app/internal/chords"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var streamPattern = regexp.MustCompile(`(?s)stream\n(.*?)endstream`)
+
+// pdfPages returns the content stream of every page, skipping the font
+// streams.
+func pdfPages(data []byte) []string {
+	pages := make([]string, 0)
+	for _, match := range streamPattern.FindAllSubmatch(data, -1) {
+		if bytes.HasPrefix(match[1], []byte("BT ")) {
+			pages = append(pages, string(match[1]))
+		}
+	}
+	return pages
+}
+
+// shown returns the operator showing the text in the font.
+func shown(font, s string) string {
+	return (&pdfLayout{}).encode(font, s) + " Tj"
+}
+
+func parseSheet(t *testing.T, content string) *chords.Sheet {
+	sheet, err := chords.ParseChordPro(content)
+	if err != nil {
+		t.Fatalf("failed to parse content: %v", err)
+	}
+	return sheet
+}
+
+func TestPDF_Layout(t *testing.T) {
+	song := Song{
+		Title:   "Wonderwall",
+		Artists: []string{"Oasis", "Noel Gallagher"},
+		Key:     "F#m",
+		Capo:    2,
+		Sheet:   parseSheet(t, "{title: Wonderwall}\n{c: Intro}\n[Em7]Today is [G]gonna be (the) day\n\n{soc}\nAnd [C]all the roads\n{eoc}"),
+	}
+
+	data := PDF(song)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(data, []byte("%%EOF\n")))
+	assert.Equal(t, data, PDF(song), "expected deterministic output")
+
+	pages := pdfPages(data)
+	assert.Len(t, pages, 1)
+	page := pages[0]
+	assert.Contains(t, page, "/F4 18 Tf 56 765 Td "+shown(fontHeadings, "Wonderwall"))
+	assert.Contains(t, page, shown(fontDetails, "Oasis, Noel Gallagher"))
+	assert.Contains(t, page, shown(fontDetails, "Key: F#m   Capo: 2"))
+	assert.Contains(t, page, "/F1 10 Tf 1 0 0.2 1 56 ")
+	assert.Contains(t, page, shown(fontLyrics, "Intro"))
+	assert.Contains(t, page, "/F2 10 Tf 56")
+	assert.Contains(t, page, shown(fontChords, "Em7      G"))
+	assert.Contains(t, page, shown(fontLyrics, "Today is gonna be (the) day"))
+	assert.Contains(t, page, shown(fontHeadings, "Chorus"))
+	assert.NotContains(t, page, shown(fontHeadings, "Wonderwall")+" ET\nBT /F1", "expected the title directive not to be printed")
+}
+
+func TestPDF_CrossReferenceTable(t *testing.T) {
+	data := PDF(Song{Title: "Song", Sheet: parseSheet(t, "[C]La")})
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	assert.True(t, bytes.HasPrefix(data[xref:], []byte("xref\n")))
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data, -1)
+	assert.NotEmpty(t, entries)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(t, bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))), "object %d", i+1)
+	}
+}
+
+func TestPDF_DoesNotSplitSections(t *testing.T) {
+	var content strings.Builder
+	for verse := 1; verse <= 12; verse++ {
+		fmt.Fprintf(&content, "{start_of_verse: Verse %d}\n", verse)
+		for line := 1; line <= 6; line++ {
+			fmt.Fprintf(&content, "[Am]Verse %d line %d\n", verse, line)
+		}
+		content.WriteString("{end_of_verse}\n")
+	}
+
+	pages := pdfPages(PDF(Song{Title: "Long", Sheet: parseSheet(t, content.String())}))
+	assert.Greater(t, len(pages), 1)
+
+	for verse := 1; verse <= 12; verse++ {
+		for i, page := range pages {
+			if strings.Contains(page, shown(fontHeadings, fmt.Sprintf("Verse %d", verse))) {
+				assert.Contains(t, page, shown(fontLyrics, fmt.Sprintf("Verse %d line 6", verse)), "expected verse %d to end on page %d", verse, i+1)
+			}
+		}
+	}
+	assert.Contains(t, pages[0], shown(fontDetails, fmt.Sprintf("1 / %d", len(pages))))
+}
+
+var positionPattern = regexp.MustCompile(`/(F\d) (\d+) Tf (?:[\d.]+ ){0,4}(\d+) (\d+) T[dm] <([0-9A-F]*)>`)
+
+// assertInsideMargins checks that every line of text but the page numbers
+// starts below the top margin and above the bottom one, and ends before
+// the right margin.
+func assertInsideMargins(t *testing.T, pages []string) {
+	for i, page := range pages {
+		for _, match := range positionPattern.FindAllStringSubmatch(page, -1) {
+			size, _ := strconv.Atoi(match[2])
+			if size == footerSize {
+				continue
+			}
+			x, _ := strconv.Atoi(match[3])
+			y, _ := strconv.Atoi(match[4])
+			font := pdfFont(match[1])
+			width := 0
+			for at := 0; at < len(match[5]); at += 4 {
+				glyph, _ := strconv.ParseUint(match[5][at:at+4], 16, 16)
+				width += int(font.widths[glyph])
+			}
+			assert.GreaterOrEqual(t, y, pageMargin, "expected page %d to keep the bottom margin", i+1)
+			assert.LessOrEqual(t, y, pageHeight-pageMargin, "expected page %d to keep the top margin", i+1)
+			assert.LessOrEqual(t, x+width*size/font.unitsPerEm, pageWidth-pageMargin, "expected page %d to keep the right margin", i+1)
+		}
+	}
+}
+
+func TestPDF_WrapsLongHeader(t *testing.T) {
+	song := Song{
+		Title:   "Somewhere Over the Rainbow / What a Wonderful World (Live at the Hollywood Bowl)",
+		Artists: []string{"Israel Kamakawiwoʻole", "The Makaha Sons", "Jerry Santos", "Louis Armstrong", "Ella Fitzgerald"},
+		Sheet:   parseSheet(t, "[C]Somewhere"),
+	}
+
+	pages := pdfPages(PDF(song))
+	assert.Contains(t, pages[0], shown(fontHeadings, "Somewhere Over the Rainbow / What a"))
+	assert.Contains(t, pages[0], shown(fontHeadings, "Wonderful World (Live at the Hollywood Bowl)"))
+	assertInsideMargins(t, pages)
+}
+
+func TestPDF_SplitsStavesLongerThanPage(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("{start_of_tab}\n")
+	for line := 1; line <= 100; line++ {
+		fmt.Fprintf(&content, "e|--%d--|\n", line%12)
+	}
+	content.WriteString("{end_of_tab}\n")
+
+	pages := pdfPages(PDF(Song{Title: "Solo", Sheet: parseSheet(t, content.String())}))
+	assert.Len(t, pages, 2)
+	assert.Equal(t, 100, strings.Count(strings.Join(pages, ""), "/"+fontLyrics+" "), "expected every line of the staff to be drawn")
+	assertInsideMargins(t, pages)
+}
+
+func TestWrapText(t *testing.T) {
+	title := "Somewhere Over the Rainbow / What a Wonderful World"
+	assert.Equal(t, []string{title}, wrapText(fontDetails, detailsSize, title))
+	assert.Equal(t, []string{""}, wrapText(fontDetails, detailsSize, " "))
+
+	word := strings.Repeat("Щ", 100)
+	lines := wrapText(fontHeadings, titleSize, word)
+	assert.Greater(t, len(lines), 1, "expected a word wider than the page to be broken")
+	assert.Equal(t, word, strings.Join(lines, ""))
+}
+
+func TestPDF_Cyrillic(t *testing.T) {
+	song := Song{
+		Title: "Группа крови",
+		Sheet: parseSheet(t, "[Am]Тёплое [C]место\n{new_page}\n[F]Щ"),
+	}
+
+	data := PDF(song)
+	pages := pdfPages(data)
+	assert.Len(t, pages, 2)
+	assert.Contains(t, pages[0], shown(fontHeadings, "Группа крови"))
+	assert.Contains(t, pages[0], shown(fontChords, "Am     C"))
+	assert.Contains(t, pages[0], shown(fontLyrics, "Тёплое место"))
+	assert.Contains(t, pages[1], shown(fontLyrics, "Щ"))
+
+	glyph := pdfFonts[0].glyphs['Щ']
+	assert.NotZero(t, glyph)
+	assert.Contains(t, string(data), fmt.Sprintf("<%04X> <0429>", glyph), "expected a ToUnicode entry for Щ")
+	assert.Contains(t, string(data), "/Subtype /CIDFontType2")
+	assert.Contains(t, string(data), "/Encoding /Identity-H")
+}
+
+func TestPDF_ReplacesMissingGlyphs(t *testing.T) {
+	assert.Equal(t, shown(fontLyrics, "a?b"), shown(fontLyrics, "a\u4e00b"))
+	assert.Equal(t, shown(fontLyrics, "a b"), shown(fontLyrics, "a\tb"))
+}
+
+func TestTrueTypeSubset(t *testing.T) {
+	font := pdfFonts[0]
+	used := map[uint16]bool{font.glyphs['Щ']: true, font.glyphs['é']: true}
+
+	subset, err := parseTrueTypeTables(font.subset(used))
+	assert.NoError(t, err)
+	assert.Less(t, len(font.subset(used)), 20000)
+	for id := range used {
+		assert.Equal(t, font.glyph(id), subset.glyph(id)[:len(font.glyph(id))])
+		for _, component := range font.components(id) {
+			assert.NotEmpty(t, subset.glyph(component), "expected component %d of glyph %d", component, id)
+		}
+	}
+	assert.Empty(t, subset.glyph(font.glyphs['A']))
+	assert.Equal(t, font.widths[font.glyphs['Щ']], subset.widths[font.glyphs['Щ']])
+}
+
+func TestWrapUnit(t *testing.T) {
+	u := unit{{chordsRow, "C    G"}, {lyricsRow, "abcd efgh"}}
+
+	assert.Equal(t, unit{
+		{chordsRow, "C"}, {lyricsRow, "abcd"},
+		{chordsRow, "G"}, {lyricsRow, "efgh"},
+	}, wrapUnit(u, 5))
+	assert.Equal(t, u, wrapUnit(u, 80))
+}
+
+func TestFilename(t *testing.T) {
+	assert.Equal(t, "wonderwall-live.pdf", Filename("Wonderwall (Live)", "pdf"))
+	assert.Equal(t, "gruppa-krovi.pdf", Filename("Группа крови", "pdf"))
+	assert.Equal(t, "song.pdf", Filename("?!", "pdf"))
+}
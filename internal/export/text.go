@@ -0,0 +1,32 @@
+package export
+
+import (
+	"strings"
+)
+
+// Text writes the song as plain text with the chords over the lyrics, for
+// reading in a monospaced font.
+func Text(song Song) []byte {
+	var b strings.Builder
+	b.WriteString(song.Title + "\n")
+	for _, line := range song.details() {
+		b.WriteString(line + "\n")
+	}
+
+	for _, block := range layoutBlocks(song.Sheet) {
+		b.WriteString("\n")
+		for _, u := range block.units {
+			for _, r := range u {
+				switch r.style {
+				case labelRow:
+					b.WriteString(r.text + ":\n")
+				case commentRow:
+					b.WriteString("(" + r.text + ")\n")
+				default:
+					b.WriteString(r.text + "\n")
+				}
+			}
+		}
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,377 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// trueTypeFont is the part of a TrueType font the PDF writer needs: the
+// character map, glyph widths and outlines for subsetting, and the metrics
+// of the font descriptor.
+type trueTypeFont struct {
+	name       string
+	tables     map[string][]byte
+	unitsPerEm int
+	bbox       [4]int
+	ascent     int
+	descent    int
+	capHeight  int
+	fixedPitch bool
+	numGlyphs  int
+	glyphs     map[rune]uint16
+	widths     []uint16
+	// offsets has the start of every glyph in glyf, and one more entry
+	// for the end of the last glyph.
+	offsets []uint32
+}
+
+// Tables copied to the subset. The rest, such as name, post and the
+// OpenType layout tables, are not read by PDF viewers.
+var subsetTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+const (
+	// Flags of composite glyph components, see the glyf table spec.
+	argsAreWords      = 0x0001
+	haveScale         = 0x0008
+	moreComponents    = 0x0020
+	haveXYScale       = 0x0040
+	haveTwoByTwo      = 0x0080
+	compositeContours = -1
+)
+
+func mustParseTrueType(name string, data []byte) *trueTypeFont {
+	font, err := parseTrueType(name, data)
+	if err != nil {
+		panic(fmt.Sprintf("font %s: %v", name, err))
+	}
+	return font
+}
+
+func parseTrueType(name string, data []byte) (*trueTypeFont, error) {
+	font, err := parseTrueTypeTables(data)
+	if err != nil {
+		return nil, err
+	}
+	font.name = name
+
+	glyphs, err := parseCmap(font.tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	font.glyphs = glyphs
+
+	if post := font.tables["post"]; len(post) >= 16 {
+		font.fixedPitch = u32(post, 12) != 0
+	}
+	font.capHeight = font.ascent
+	if glyph := font.glyph(font.glyphs['H']); len(glyph) >= 10 {
+		font.capHeight = int(int16(u16(glyph, 8)))
+	}
+	return font, nil
+}
+
+// parseTrueTypeTables reads the metrics and glyph locations, which is all
+// a font subset has.
+func parseTrueTypeTables(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errors.New("truncated table directory")
+	}
+	font := &trueTypeFont{tables: make(map[string][]byte)}
+	count := int(u16(data, 4))
+	for i := 0; i < count; i++ {
+		entry := 12 + 16*i
+		if entry+16 > len(data) {
+			return nil, errors.New("truncated table directory")
+		}
+		offset, length := u32(data, entry+8), u32(data, entry+12)
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, fmt.Errorf("table %q is out of bounds", data[entry:entry+4])
+		}
+		font.tables[string(data[entry:entry+4])] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"glyf", "head", "hhea", "hmtx", "loca", "maxp"} {
+		if _, ok := font.tables[tag]; !ok {
+			return nil, fmt.Errorf("missing %q table", tag)
+		}
+	}
+
+	head := font.tables["head"]
+	font.unitsPerEm = int(u16(head, 18))
+	for i := range font.bbox {
+		font.bbox[i] = int(int16(u16(head, 36+2*i)))
+	}
+	hhea := font.tables["hhea"]
+	font.ascent = int(int16(u16(hhea, 4)))
+	font.descent = int(int16(u16(hhea, 6)))
+	font.numGlyphs = int(u16(font.tables["maxp"], 4))
+
+	hmtx := font.tables["hmtx"]
+	metrics := int(u16(hhea, 34))
+	if metrics == 0 || len(hmtx) < 4*metrics {
+		return nil, errors.New("truncated hmtx table")
+	}
+	font.widths = make([]uint16, font.numGlyphs)
+	for i := range font.widths {
+		font.widths[i] = u16(hmtx, 4*min(i, metrics-1))
+	}
+
+	loca := font.tables["loca"]
+	font.offsets = make([]uint32, font.numGlyphs+1)
+	longOffsets := u16(head, 50) == 1
+	for i := range font.offsets {
+		switch {
+		case longOffsets && len(loca) >= 4*i+4:
+			font.offsets[i] = u32(loca, 4*i)
+		case !longOffsets && len(loca) >= 2*i+2:
+			font.offsets[i] = 2 * uint32(u16(loca, 2*i))
+		default:
+			return nil, errors.New("truncated loca table")
+		}
+		if font.offsets[i] > uint32(len(font.tables["glyf"])) {
+			return nil, errors.New("loca points past the glyf table")
+		}
+	}
+	return font, nil
+}
+
+// parseCmap reads the Windows Unicode BMP subtable (format 4), which
+// covers Latin and Cyrillic.
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errors.New("truncated cmap table")
+	}
+	for i := 0; i < int(u16(cmap, 2)); i++ {
+		record := 4 + 8*i
+		if record+8 > len(cmap) {
+			break
+		}
+		platform, encoding, offset := u16(cmap, record), u16(cmap, record+2), int(u32(cmap, record+4))
+		if platform != 3 || encoding != 1 || offset+14 > len(cmap) || u16(cmap, offset) != 4 {
+			continue
+		}
+		return parseCmapFormat4(cmap[offset:])
+	}
+	return nil, errors.New("no Unicode cmap subtable")
+}
+
+func parseCmapFormat4(table []byte) (map[rune]uint16, error) {
+	segments := int(u16(table, 6)) / 2
+	endCodes := 14
+	startCodes := endCodes + 2*segments + 2
+	deltas := startCodes + 2*segments
+	rangeOffsets := deltas + 2*segments
+	if rangeOffsets+2*segments > len(table) {
+		return nil, errors.New("truncated cmap subtable")
+	}
+
+	glyphs := make(map[rune]uint16)
+	for i := 0; i < segments; i++ {
+		end, start := int(u16(table, endCodes+2*i)), int(u16(table, startCodes+2*i))
+		delta, rangeOffset := u16(table, deltas+2*i), int(u16(table, rangeOffsets+2*i))
+		for c := start; c <= end && c != 0xffff; c++ {
+			glyph := uint16(c) + delta
+			if rangeOffset != 0 {
+				at := rangeOffsets + 2*i + rangeOffset + 2*(c-start)
+				if at+2 > len(table) {
+					return nil, errors.New("truncated cmap subtable")
+				}
+				if glyph = u16(table, at); glyph != 0 {
+					glyph += delta
+				}
+			}
+			if glyph != 0 {
+				glyphs[rune(c)] = glyph
+			}
+		}
+	}
+	return glyphs, nil
+}
+
+func (f *trueTypeFont) glyph(id uint16) []byte {
+	if int(id) >= f.numGlyphs {
+		return nil
+	}
+	return f.tables["glyf"][f.offsets[id]:f.offsets[id+1]]
+}
+
+// advance returns the width of the character in font units, the width of
+// ? for characters the font lacks as the PDF writer replaces them.
+func (f *trueTypeFont) advance(r rune) int {
+	glyph, ok := f.glyphs[r]
+	if !ok {
+		glyph = f.glyphs['?']
+	}
+	return int(f.widths[glyph])
+}
+
+// scale converts font units to the thousandths of an em PDF uses.
+func (f *trueTypeFont) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// components returns the glyphs a composite glyph is made of.
+func (f *trueTypeFont) components(id uint16) []uint16 {
+	glyph := f.glyph(id)
+	if len(glyph) < 10 || int16(u16(glyph, 0)) != compositeContours {
+		return nil
+	}
+	components := make([]uint16, 0)
+	for at := 10; at+4 <= len(glyph); {
+		flags := u16(glyph, at)
+		components = append(components, u16(glyph, at+2))
+		at += 4
+		if flags&argsAreWords != 0 {
+			at += 4
+		} else {
+			at += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			at += 2
+		case flags&haveXYScale != 0:
+			at += 4
+		case flags&haveTwoByTwo != 0:
+			at += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return components
+}
+
+// subset returns a font with the outlines of the given glyphs and their
+// components only. Glyph IDs stay the same, the other glyphs are left
+// empty, so the PDF can use the IDs as CIDs with an identity mapping.
+func (f *trueTypeFont) subset(used map[uint16]bool) []byte {
+	keep := map[uint16]bool{0: true}
+	pending := make([]uint16, 0, len(used))
+	for id := range used {
+		pending = append(pending, id)
+	}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if keep[id] || int(id) >= f.numGlyphs {
+			continue
+		}
+		keep[id] = true
+		pending = append(pending, f.components(id)...)
+	}
+
+	last := 0
+	for id := range keep {
+		last = max(last, int(id))
+	}
+	numGlyphs := last + 1
+
+	var glyf bytes.Buffer
+	loca := make([]byte, 4*(numGlyphs+1))
+	for id := 0; id < numGlyphs; id++ {
+		if keep[uint16(id)] {
+			glyf.Write(f.glyph(uint16(id)))
+			for glyf.Len()%4 != 0 {
+				glyf.WriteByte(0)
+			}
+		}
+		binary.BigEndian.PutUint32(loca[4*id+4:], uint32(glyf.Len()))
+	}
+
+	hmtx := make([]byte, 4*numGlyphs)
+	for id := 0; id < numGlyphs; id++ {
+		binary.BigEndian.PutUint16(hmtx[4*id:], f.widths[id])
+		copy(hmtx[4*id+2:4*id+4], f.leftSideBearing(id))
+	}
+
+	tables := map[string][]byte{
+		"glyf": glyf.Bytes(),
+		"loca": loca,
+		"hmtx": hmtx,
+		"head": bytes.Clone(f.tables["head"]),
+		"hhea": bytes.Clone(f.tables["hhea"]),
+		"maxp": bytes.Clone(f.tables["maxp"]),
+	}
+	// The checksum adjustment is left at zero, viewers do not check it.
+	binary.BigEndian.PutUint32(tables["head"][8:], 0)
+	binary.BigEndian.PutUint16(tables["head"][50:], 1)
+	binary.BigEndian.PutUint16(tables["hhea"][34:], uint16(numGlyphs))
+	binary.BigEndian.PutUint16(tables["maxp"][4:], uint16(numGlyphs))
+	for _, tag := range []string{"cvt ", "fpgm", "prep"} {
+		if table, ok := f.tables[tag]; ok {
+			tables[tag] = table
+		}
+	}
+	return writeTrueType(tables)
+}
+
+func (f *trueTypeFont) leftSideBearing(id int) []byte {
+	hmtx := f.tables["hmtx"]
+	metrics := int(u16(f.tables["hhea"], 34))
+	if id < metrics {
+		return hmtx[4*id+2 : 4*id+4]
+	}
+	at := 4*metrics + 2*(id-metrics)
+	if at+2 > len(hmtx) {
+		return []byte{0, 0}
+	}
+	return hmtx[at : at+2]
+}
+
+// writeTrueType assembles a font file from its tables, sorted by tag as
+// the spec requires.
+func writeTrueType(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(subsetTables))
+	for _, tag := range subsetTables {
+		if _, ok := tables[tag]; ok {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	searchRange, selector := 1, 0
+	for searchRange*2 <= len(tags) {
+		searchRange *= 2
+		selector++
+	}
+
+	var b bytes.Buffer
+	b.Write([]byte{0, 1, 0, 0})
+	binary.Write(&b, binary.BigEndian, []uint16{
+		uint16(len(tags)), uint16(16 * searchRange), uint16(selector), uint16(16 * (len(tags) - searchRange)),
+	})
+	offset := 12 + 16*len(tags)
+	for _, tag := range tags {
+		table := tables[tag]
+		b.WriteString(tag)
+		binary.Write(&b, binary.BigEndian, []uint32{checksum(table), uint32(offset), uint32(len(table))})
+		offset += (len(table) + 3) &^ 3
+	}
+	for _, tag := range tags {
+		b.Write(tables[tag])
+		for b.Len()%4 != 0 {
+			b.WriteByte(0)
+		}
+	}
+	return b.Bytes()
+}
+
+func checksum(table []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(table); i += 4 {
+		var word [4]byte
+		copy(word[:], table[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+func u16(b []byte, at int) uint16 {
+	return binary.BigEndian.Uint16(b[at:])
+}
+
+func u32(b []byte, at int) uint32 {
+	return binary.BigEndian.Uint32(b[at:])
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -28,6 +30,26 @@ type Song struct {
 	Content     string
 	Artists     []SongArtist `gorm:"constraint:OnDelete:CASCADE;"`
 	UploadedBy  uint
+	Tags        []Tag `gorm:"many2many:song_tags;"`
+	// Capo is the fret the chords in Content are played with a capo on,
+	// zero when they are played without one.
+	Capo uint
+	// Key is the concert key detected from the chords, such as "Am" or
+	// "Bb", and Mode is "major" or "minor". Both are empty when the song
+	// has no chords.
+	Key  string `gorm:"index"`
+	Mode string `gorm:"index"`
+	// Instrument is what the song is played on and Tuning the name of
+	// its tuning, "custom" for other open notes. TuningNotes spells the
+	// open strings lowest first, like "D A D G B E".
+	Instrument  string `gorm:"index;default:guitar"`
+	Tuning      string `gorm:"index;default:standard"`
+	TuningNotes string
+}
+
+type Tag struct {
+	gorm.Model
+	Name string `gorm:"uniqueIndex"`
 }
 
 type SongArtist struct {
@@ -43,3 +65,41 @@ type SongRequest struct {
 	gorm.Model
 	SongID uint
 }
+
+// SearchOutbox is a pending search index operation written in the same
+// transaction as the change it mirrors.
+type SearchOutbox struct {
+	gorm.Model
+	Operation     string
+	ObjType       string
+	ObjID         uint
+	Attempts      uint
+	NextAttemptAt time.Time `gorm:"index"`
+	LastError     string
+}
+
+// ImportJob is a bulk import of an archive of ChordPro files. The archive
+// is kept until the job is done so an interrupted job can resume, files
+// that already have an ImportJobFile are not imported again. A running
+// job renews HeartbeatAt with every file, a job whose heartbeat stopped
+// was interrupted and may be taken over.
+type ImportJob struct {
+	gorm.Model
+	Filename    string
+	Archive     []byte
+	Status      string `gorm:"index"`
+	UploadedBy  uint
+	Error       string
+	HeartbeatAt time.Time
+	Files       []ImportJobFile `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// ImportJobFile is the outcome of importing one file of an archive.
+type ImportJobFile struct {
+	gorm.Model
+	ImportJobID uint `gorm:"index"`
+	Path        string
+	Status      string
+	SongID      uint
+	Message     string
+}
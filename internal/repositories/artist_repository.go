@@ -3,68 +3,77 @@ package repositories
 import (
 	"chords_app/internal/models"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type ArtistRepository interface {
-	BeginTransaction() *gorm.DB
-	CreateArtist(artist *models.Artist) error
-	GetArtists() (*[]models.Artist, error)
-	GetArtistById(artistId uint) (*models.Artist, error)
-	GetArtistSongs(artistId uint) (*[]models.Song, error)
-	UpdateArtist(artist *models.Artist) error
-	DeleteArtist(artist *models.Artist) error
+	CreateArtist(db *gorm.DB, artist *models.Artist) error
+	GetArtists(db *gorm.DB) (*[]models.Artist, error)
+	GetArtistById(db *gorm.DB, artistId uint) (*models.Artist, error)
+	GetArtistByName(db *gorm.DB, name string) (*models.Artist, error)
+	GetArtistSongs(db *gorm.DB, artistId uint) (*[]models.Song, error)
+	UpdateArtist(db *gorm.DB, artist *models.Artist) error
+	DeleteArtist(db *gorm.DB, artist *models.Artist) error
+	FindArtistsInBatches(db *gorm.DB, batchSize int, fn func(artists *[]models.Artist) error) error
+	GetChangedArtistIds(db *gorm.DB, since time.Time) ([]uint, error)
 }
 
-type gormArtistRepository struct {
-	db *gorm.DB
-}
-
-func NewGormArtistRepository(db *gorm.DB) ArtistRepository {
-	return &gormArtistRepository{db: db}
-}
+type gormArtistRepository struct{}
 
-func (r *gormArtistRepository) BeginTransaction() *gorm.DB {
-	return r.db.Begin()
+func NewGormArtistRepository() ArtistRepository {
+	return &gormArtistRepository{}
 }
 
-func (r *gormArtistRepository) CreateArtist(artist *models.Artist) error {
-	return r.db.Create(artist).Error
+func (r *gormArtistRepository) CreateArtist(db *gorm.DB, artist *models.Artist) error {
+	return db.Create(artist).Error
 }
 
-func (r *gormArtistRepository) GetArtists() (*[]models.Artist, error) {
+func (r *gormArtistRepository) GetArtists(db *gorm.DB) (*[]models.Artist, error) {
 	var artists []models.Artist
 
-	result := r.db.Find(&artists)
+	result := db.Find(&artists)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return &artists, nil
 	}
 	return &artists, result.Error
 }
 
-func (r *gormArtistRepository) GetArtistById(artistId uint) (*models.Artist, error) {
+func (r *gormArtistRepository) GetArtistById(db *gorm.DB, artistId uint) (*models.Artist, error) {
 	var artist models.Artist
 
-	result := r.db.Where("id = ?", artistId).First(&artist)
+	result := db.Where("id = ?", artistId).First(&artist)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
 	return &artist, result.Error
 }
 
-func (r *gormArtistRepository) UpdateArtist(artist *models.Artist) error {
-	return r.db.Save(artist).Error
+// GetArtistByName finds an artist by name ignoring case, returning nil
+// when there is none.
+func (r *gormArtistRepository) GetArtistByName(db *gorm.DB, name string) (*models.Artist, error) {
+	var artist models.Artist
+
+	result := db.Where("LOWER(name) = LOWER(?)", name).Order("id").First(&artist)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &artist, result.Error
 }
 
-func (r *gormArtistRepository) DeleteArtist(artist *models.Artist) error {
-	return r.db.Delete(artist).Error
+func (r *gormArtistRepository) UpdateArtist(db *gorm.DB, artist *models.Artist) error {
+	return db.Save(artist).Error
 }
 
-func (r *gormArtistRepository) GetArtistSongs(artistId uint) (*[]models.Song, error) {
+func (r *gormArtistRepository) DeleteArtist(db *gorm.DB, artist *models.Artist) error {
+	return db.Delete(artist).Error
+}
+
+func (r *gormArtistRepository) GetArtistSongs(db *gorm.DB, artistId uint) (*[]models.Song, error) {
 	var songs []models.Song
 
-	result := r.db.Model(&models.Song{}).
+	result := db.Model(&models.Song{}).
 		Joins("JOIN song_artists ON song_artists.song_id = songs.id").
 		Where("song_artists.artist_id = ?", artistId).
 		Preload("Artists", func(db *gorm.DB) *gorm.DB {
@@ -81,3 +90,24 @@ func (r *gormArtistRepository) GetArtistSongs(artistId uint) (*[]models.Song, er
 	}
 	return &songs, nil
 }
+
+func (r *gormArtistRepository) FindArtistsInBatches(db *gorm.DB, batchSize int, fn func(artists *[]models.Artist) error) error {
+	var artists []models.Artist
+
+	return db.FindInBatches(&artists, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(&artists)
+	}).Error
+}
+
+// GetChangedArtistIds returns the IDs of artists created, updated or
+// deleted since the given time.
+func (r *gormArtistRepository) GetChangedArtistIds(db *gorm.DB, since time.Time) ([]uint, error) {
+	var ids []uint
+
+	err := db.Unscoped().
+		Model(&models.Artist{}).
+		Where("updated_at >= ? OR deleted_at >= ?", since, since).
+		Pluck("id", &ids).Error
+
+	return ids, err
+}
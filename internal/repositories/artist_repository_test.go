@@ -18,7 +18,7 @@ func setupTestDB() (*gorm.DB, error) {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Artist{}, &models.Song{}, &models.SongArtist{})
+	err = db.AutoMigrate(&models.User{}, &models.Artist{}, &models.Song{}, &models.SongArtist{}, &models.Tag{})
 	if err != nil {
 		return nil, err
 	}
@@ -32,11 +32,11 @@ func TestCreateArtist(t *testing.T) {
 		t.Fatalf("failed to setup test DB: %v", err)
 	}
 
-	repo := NewGormArtistRepository(db)
+	repo := NewGormArtistRepository()
 
 	artist := models.Artist{Name: "New Artist"}
 
-	err = repo.CreateArtist(&artist)
+	err = repo.CreateArtist(db, &artist)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -51,7 +51,7 @@ func TestGetArtists(t *testing.T) {
 		t.Fatalf("failed to setup test DB: %v", err)
 	}
 
-	repo := NewGormArtistRepository(db)
+	repo := NewGormArtistRepository()
 
 	artist1 := models.Artist{Name: "Artist 1"}
 	artist2 := models.Artist{Name: "Artist 2"}
@@ -59,7 +59,7 @@ func TestGetArtists(t *testing.T) {
 	db.Create(&artist1)
 	db.Create(&artist2)
 
-	artists, err := repo.GetArtists()
+	artists, err := repo.GetArtists(db)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -75,12 +75,12 @@ func TestGetArtistById(t *testing.T) {
 		t.Fatalf("failed to setup test DB: %v", err)
 	}
 
-	repo := NewGormArtistRepository(db)
+	repo := NewGormArtistRepository()
 
 	artist := models.Artist{Name: "Artist By ID"}
 	db.Create(&artist)
 
-	retrievedArtist, err := repo.GetArtistById(artist.ID)
+	retrievedArtist, err := repo.GetArtistById(db, artist.ID)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -95,9 +95,9 @@ func TestGetArtistById_NotFound(t *testing.T) {
 		t.Fatalf("failed to setup test DB: %v", err)
 	}
 
-	repo := NewGormArtistRepository(db)
+	repo := NewGormArtistRepository()
 
-	retrievedArtist, err := repo.GetArtistById(999)
+	retrievedArtist, err := repo.GetArtistById(db, 999)
 	assert.Nil(t, retrievedArtist, "expected no artist to be found")
 	assert.NoError(t, err, "expected no error")
 }
@@ -108,7 +108,7 @@ func TestGetArtistSongs_WithSongs(t *testing.T) {
 		t.Fatalf("failed to setup test DB: %v", err)
 	}
 
-	repo := NewGormArtistRepository(db)
+	repo := NewGormArtistRepository()
 
 	artist := models.Artist{Name: "Test Artist"}
 	song1 := models.Song{Title: "Song 1", Description: "Description 1", Content: "Content 1"}
@@ -124,7 +124,7 @@ func TestGetArtistSongs_WithSongs(t *testing.T) {
 	db.Create(&songArtist1)
 	db.Create(&songArtist2)
 
-	songs, err := repo.GetArtistSongs(artist.ID)
+	songs, err := repo.GetArtistSongs(db, artist.ID)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -140,12 +140,12 @@ func TestGetArtistSongs_WithoutSongs(t *testing.T) {
 		t.Fatalf("failed to setup test DB: %v", err)
 	}
 
-	repo := NewGormArtistRepository(db)
+	repo := NewGormArtistRepository()
 
 	artistNoSongs := models.Artist{Name: "No Songs Artist"}
 	db.Create(&artistNoSongs)
 
-	songs, err := repo.GetArtistSongs(artistNoSongs.ID)
+	songs, err := repo.GetArtistSongs(db, artistNoSongs.ID)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -159,9 +159,9 @@ func TestGetArtistSongs_InvalidArtistID(t *testing.T) {
 		t.Fatalf("failed to setup test DB: %v", err)
 	}
 
-	repo := NewGormArtistRepository(db)
+	repo := NewGormArtistRepository()
 
-	songs, err := repo.GetArtistSongs(999)
+	songs, err := repo.GetArtistSongs(db, 999)
 	assert.Nil(t, songs, "expected no songs")
 	assert.NoError(t, err, "expected no error")
 }
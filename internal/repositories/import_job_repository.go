@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"chords_app/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ImportJobPending = "pending"
+	ImportJobRunning = "running"
+	ImportJobDone    = "done"
+	ImportJobFailed  = "failed"
+)
+
+type ImportJobRepository interface {
+	CreateJob(db *gorm.DB, job *models.ImportJob) error
+	GetJob(db *gorm.DB, jobId uint) (*models.ImportJob, error)
+	GetJobWithFiles(db *gorm.DB, jobId uint) (*models.ImportJob, error)
+	GetUnfinishedJobIds(db *gorm.DB, staleBefore time.Time) ([]uint, error)
+	ClaimJob(db *gorm.DB, jobId uint, now, staleBefore time.Time) (bool, error)
+	Heartbeat(db *gorm.DB, jobId uint, now time.Time) error
+	UpdateJob(db *gorm.DB, job *models.ImportJob) error
+	AddFile(db *gorm.DB, file *models.ImportJobFile) error
+	GetImportedPaths(db *gorm.DB, jobId uint) ([]string, error)
+}
+
+type gormImportJobRepository struct{}
+
+func NewGormImportJobRepository() ImportJobRepository {
+	return &gormImportJobRepository{}
+}
+
+func (r *gormImportJobRepository) CreateJob(db *gorm.DB, job *models.ImportJob) error {
+	return db.Create(job).Error
+}
+
+func (r *gormImportJobRepository) GetJob(db *gorm.DB, jobId uint) (*models.ImportJob, error) {
+	var job models.ImportJob
+
+	err := db.Where("id = ?", jobId).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("import job not found")
+	}
+	return &job, err
+}
+
+// GetJobWithFiles loads the job and its file outcomes in import order,
+// without the archive.
+func (r *gormImportJobRepository) GetJobWithFiles(db *gorm.DB, jobId uint) (*models.ImportJob, error) {
+	var job models.ImportJob
+
+	err := db.Omit("archive").
+		Preload("Files", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		Where("id = ?", jobId).
+		First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("import job not found")
+	}
+	return &job, err
+}
+
+// GetUnfinishedJobIds returns pending jobs and running jobs without a
+// heartbeat since staleBefore, which were interrupted, oldest first.
+func (r *gormImportJobRepository) GetUnfinishedJobIds(db *gorm.DB, staleBefore time.Time) ([]uint, error) {
+	var ids []uint
+
+	err := claimableJobs(db.Model(&models.ImportJob{}), staleBefore).
+		Order("id").
+		Pluck("id", &ids).Error
+
+	return ids, err
+}
+
+// ClaimJob marks a pending or interrupted job as running in a single
+// update and reports whether it did, so only one worker runs the job.
+func (r *gormImportJobRepository) ClaimJob(db *gorm.DB, jobId uint, now, staleBefore time.Time) (bool, error) {
+	result := claimableJobs(db.Model(&models.ImportJob{}), staleBefore).
+		Where("id = ?", jobId).
+		Updates(map[string]interface{}{"status": ImportJobRunning, "heartbeat_at": now})
+
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *gormImportJobRepository) Heartbeat(db *gorm.DB, jobId uint, now time.Time) error {
+	return db.Model(&models.ImportJob{}).
+		Where("id = ?", jobId).
+		Update("heartbeat_at", now).Error
+}
+
+func claimableJobs(db *gorm.DB, staleBefore time.Time) *gorm.DB {
+	return db.Where("(status = ? OR (status = ? AND heartbeat_at < ?))", ImportJobPending, ImportJobRunning, staleBefore)
+}
+
+func (r *gormImportJobRepository) UpdateJob(db *gorm.DB, job *models.ImportJob) error {
+	return db.Save(job).Error
+}
+
+func (r *gormImportJobRepository) AddFile(db *gorm.DB, file *models.ImportJobFile) error {
+	return db.Create(file).Error
+}
+
+func (r *gormImportJobRepository) GetImportedPaths(db *gorm.DB, jobId uint) ([]string, error) {
+	var paths []string
+
+	err := db.Model(&models.ImportJobFile{}).
+		Where("import_job_id = ?", jobId).
+		Pluck("path", &paths).Error
+
+	return paths, err
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"chords_app/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OutboxIndex  = "index"
+	OutboxDelete = "delete"
+)
+
+type OutboxRepository interface {
+	Enqueue(db *gorm.DB, operation, objType string, objId uint) error
+	GetDue(db *gorm.DB, now time.Time, limit int) (*[]models.SearchOutbox, error)
+	Complete(db *gorm.DB, entry *models.SearchOutbox) error
+	Reschedule(db *gorm.DB, entry *models.SearchOutbox, nextAttemptAt time.Time, lastError string) error
+}
+
+type gormOutboxRepository struct{}
+
+func NewGormOutboxRepository() OutboxRepository {
+	return &gormOutboxRepository{}
+}
+
+func (r *gormOutboxRepository) Enqueue(db *gorm.DB, operation, objType string, objId uint) error {
+	entry := models.SearchOutbox{
+		Operation:     operation,
+		ObjType:       objType,
+		ObjID:         objId,
+		NextAttemptAt: time.Now(),
+	}
+	return db.Create(&entry).Error
+}
+
+func (r *gormOutboxRepository) GetDue(db *gorm.DB, now time.Time, limit int) (*[]models.SearchOutbox, error) {
+	var entries []models.SearchOutbox
+
+	err := db.
+		Where("next_attempt_at <= ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&entries).Error
+
+	return &entries, err
+}
+
+func (r *gormOutboxRepository) Complete(db *gorm.DB, entry *models.SearchOutbox) error {
+	return db.Unscoped().Delete(entry).Error
+}
+
+func (r *gormOutboxRepository) Reschedule(db *gorm.DB, entry *models.SearchOutbox, nextAttemptAt time.Time, lastError string) error {
+	entry.Attempts++
+	entry.NextAttemptAt = nextAttemptAt
+	entry.LastError = lastError
+	return db.Save(entry).Error
+}
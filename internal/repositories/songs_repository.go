@@ -9,22 +9,25 @@ import (
 )
 
 type SongWithViews struct {
-	ID        uint `gorm:"primarykey"`
-	Title     string
+	models.Song
 	ViewCount uint
-	Artists   []models.SongArtist `gorm:"foreignKey:SongID"`
 }
 
 type SongRepository interface {
-	GetPopularSongsForPeriod(db *gorm.DB, periodDays, limit, offset uint) (*[]SongWithViews, error)
+	GetPopularSongsForPeriod(db *gorm.DB, periodDays uint, key, mode, instrument, tuning string, limit, offset uint) (*[]SongWithViews, error)
 	CreateSong(db *gorm.DB, song *models.Song) error
 	GetSongById(db *gorm.DB, songId uint) (*models.Song, error)
 	GetSongWithArtists(db *gorm.DB, songId uint) (*models.Song, error)
+	GetSongByTitleAndArtist(db *gorm.DB, title string, artistId uint) (*models.Song, error)
 	UpdateSong(db *gorm.DB, song *models.Song) error
 	DeleteSong(db *gorm.DB, song *models.Song) error
 	AttachAuthor(db *gorm.DB, songArtist *models.SongArtist) error
 	DeattachAuthor(db *gorm.DB, songArtist *models.SongArtist) error
 	AddSongRequest(db *gorm.DB, songId uint) error
+	SetSongTags(db *gorm.DB, song *models.Song, tagNames []string) error
+	FindSongsInBatches(db *gorm.DB, batchSize int, fn func(songs *[]models.Song) error) error
+	SetSongKey(db *gorm.DB, song *models.Song) error
+	GetChangedSongIds(db *gorm.DB, since time.Time) ([]uint, error)
 }
 
 type gormSongRepository struct{}
@@ -33,7 +36,9 @@ func NewGormSongRepository() SongRepository {
 	return &gormSongRepository{}
 }
 
-func (r *gormSongRepository) GetPopularSongsForPeriod(db *gorm.DB, periodDays, limit, offset uint) (*[]SongWithViews, error) {
+// GetPopularSongsForPeriod lists songs by views in the period. Empty key,
+// mode, instrument and tuning match every song.
+func (r *gormSongRepository) GetPopularSongsForPeriod(db *gorm.DB, periodDays uint, key, mode, instrument, tuning string, limit, offset uint) (*[]SongWithViews, error) {
 	var result []SongWithViews
 
 	subquery := db.
@@ -45,10 +50,25 @@ func (r *gormSongRepository) GetPopularSongsForPeriod(db *gorm.DB, periodDays, l
 		subquery = subquery.Where("requested_at >= ?", time.Now().AddDate(0, 0, -int(periodDays)))
 	}
 
-	err := db.
+	query := db.
 		Select("songs.*, COALESCE(subquery.view_count, 0) as view_count").
 		Table("songs").
-		Joins("LEFT JOIN (?) as subquery ON songs.id = subquery.song_id", subquery).
+		Joins("LEFT JOIN (?) as subquery ON songs.id = subquery.song_id", subquery)
+
+	if key != "" {
+		query = query.Where("songs.key = ?", key)
+	}
+	if mode != "" {
+		query = query.Where("songs.mode = ?", mode)
+	}
+	if instrument != "" {
+		query = query.Where("songs.instrument = ?", instrument)
+	}
+	if tuning != "" {
+		query = query.Where("songs.tuning = ?", tuning)
+	}
+
+	err := query.
 		Preload("Artists", func(db *gorm.DB) *gorm.DB {
 			return db.Order("title_order")
 		}).
@@ -79,6 +99,7 @@ func (r *gormSongRepository) GetSongWithArtists(db *gorm.DB, songId uint) (*mode
 		Preload("Artists", func(db *gorm.DB) *gorm.DB {
 			return db.Order("title_order")
 		}).
+		Preload("Tags").
 		First(&song).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -88,6 +109,23 @@ func (r *gormSongRepository) GetSongWithArtists(db *gorm.DB, songId uint) (*mode
 	return &song, err
 }
 
+// GetSongByTitleAndArtist finds a song of the artist by title ignoring
+// case, returning nil when there is none.
+func (r *gormSongRepository) GetSongByTitleAndArtist(db *gorm.DB, title string, artistId uint) (*models.Song, error) {
+	var song models.Song
+
+	err := db.Model(&models.Song{}).
+		Joins("JOIN song_artists ON song_artists.song_id = songs.id AND song_artists.deleted_at IS NULL").
+		Where("song_artists.artist_id = ? AND LOWER(songs.title) = LOWER(?)", artistId, title).
+		Order("songs.id").
+		First(&song).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &song, err
+}
+
 func (r *gormSongRepository) UpdateSong(db *gorm.DB, song *models.Song) error {
 	return db.Save(song).Error
 }
@@ -110,3 +148,51 @@ func (r *gormSongRepository) AddSongRequest(db *gorm.DB, songId uint) error {
 	}
 	return db.Create(&songRequest).Error
 }
+
+// SetSongTags replaces the song's tags, creating tags that do not exist yet.
+func (r *gormSongRepository) SetSongTags(db *gorm.DB, song *models.Song, tagNames []string) error {
+	tags := make([]models.Tag, 0, len(tagNames))
+	for _, name := range tagNames {
+		tag := models.Tag{Name: name}
+		if err := db.Where(models.Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+			return err
+		}
+		tags = append(tags, tag)
+	}
+	return db.Model(song).Association("Tags").Replace(tags)
+}
+
+// FindSongsInBatches streams every song with its artist links, linked
+// artists and tags preloaded.
+func (r *gormSongRepository) FindSongsInBatches(db *gorm.DB, batchSize int, fn func(songs *[]models.Song) error) error {
+	var songs []models.Song
+
+	return db.
+		Preload("Artists", func(db *gorm.DB) *gorm.DB {
+			return db.Order("title_order")
+		}).
+		Preload("Artists.Artist").
+		Preload("Tags").
+		FindInBatches(&songs, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(&songs)
+		}).Error
+}
+
+// SetSongKey stores the song's key and mode without touching UpdatedAt, as
+// the song itself did not change.
+func (r *gormSongRepository) SetSongKey(db *gorm.DB, song *models.Song) error {
+	return db.Model(song).UpdateColumns(map[string]interface{}{"key": song.Key, "mode": song.Mode}).Error
+}
+
+// GetChangedSongIds returns the IDs of songs created, updated or deleted
+// since the given time.
+func (r *gormSongRepository) GetChangedSongIds(db *gorm.DB, since time.Time) ([]uint, error) {
+	var ids []uint
+
+	err := db.Unscoped().
+		Model(&models.Song{}).
+		Where("updated_at >= ? OR deleted_at >= ?", since, since).
+		Pluck("id", &ids).Error
+
+	return ids, err
+}
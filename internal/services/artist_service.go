@@ -24,13 +24,13 @@ type ArtistService interface {
 }
 
 type artistService struct {
-	repo      repositories.ArtistRepository
-	osAdapter *opensearch.OpenSearchAdapter
-	db        *gorm.DB
+	repo       repositories.ArtistRepository
+	outboxRepo repositories.OutboxRepository
+	db         *gorm.DB
 }
 
-func NewArtistService(repo repositories.ArtistRepository, osAdapter *opensearch.OpenSearchAdapter, db *gorm.DB) ArtistService {
-	return &artistService{repo, osAdapter, db}
+func NewArtistService(repo repositories.ArtistRepository, outboxRepo repositories.OutboxRepository, db *gorm.DB) ArtistService {
+	return &artistService{repo, outboxRepo, db}
 }
 
 func (s *artistService) CreateArtist(name, description, imageUrl string) (*models.Artist, error) {
@@ -42,26 +42,28 @@ func (s *artistService) CreateArtist(name, description, imageUrl string) (*model
 		ImageUrl:    imageUrl,
 	}
 
-	err := s.repo.CreateArtist(artist)
+	err := s.repo.CreateArtist(tx, artist)
 	if err != nil {
 		tx.Rollback()
 		return nil, err
 	}
-	err = s.osAdapter.IndexArtist(artist)
+	err = s.outboxRepo.Enqueue(tx, repositories.OutboxIndex, opensearch.ArtistType, artist.ID)
 	if err != nil {
 		tx.Rollback()
 		return nil, err
 	}
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
 	return artist, nil
 }
 
 func (s *artistService) GetArtists() (*[]models.Artist, error) {
-	return s.repo.GetArtists()
+	return s.repo.GetArtists(s.db)
 }
 
 func (s *artistService) UpdateArtist(artistId uint, name, description, imageUrl string) (*models.Artist, error) {
-	artist, err := s.repo.GetArtistById(artistId)
+	artist, err := s.repo.GetArtistById(s.db, artistId)
 	if err != nil {
 		return nil, err
 	}
@@ -79,8 +81,18 @@ func (s *artistService) UpdateArtist(artistId uint, name, description, imageUrl
 		artist.ImageUrl = imageUrl
 	}
 
-	err = s.repo.UpdateArtist(artist)
+	tx := s.db.Begin()
+
+	err = s.repo.UpdateArtist(tx, artist)
 	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.enqueueArtistSync(tx, repositories.OutboxIndex, artist.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
 
@@ -88,7 +100,7 @@ func (s *artistService) UpdateArtist(artistId uint, name, description, imageUrl
 }
 
 func (s *artistService) DeleteArtist(artistId uint) error {
-	artist, err := s.repo.GetArtistById(artistId)
+	artist, err := s.repo.GetArtistById(s.db, artistId)
 	if err != nil {
 		return err
 	}
@@ -96,11 +108,42 @@ func (s *artistService) DeleteArtist(artistId uint) error {
 		return errors.New("artist not found")
 	}
 
-	return s.repo.DeleteArtist(artist)
+	tx := s.db.Begin()
+
+	if err := s.repo.DeleteArtist(tx, artist); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.enqueueArtistSync(tx, repositories.OutboxDelete, artist.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// enqueueArtistSync schedules the artist document operation together with
+// a reindex of every song the artist is attached to, since songs carry
+// denormalized artist names.
+func (s *artistService) enqueueArtistSync(tx *gorm.DB, operation string, artistId uint) error {
+	if err := s.outboxRepo.Enqueue(tx, operation, opensearch.ArtistType, artistId); err != nil {
+		return err
+	}
+
+	songs, err := s.repo.GetArtistSongs(tx, artistId)
+	if err != nil || songs == nil {
+		return err
+	}
+
+	for _, song := range *songs {
+		if err := s.outboxRepo.Enqueue(tx, repositories.OutboxIndex, opensearch.SongType, song.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *artistService) GetArtistInformation(artistId uint) (*models.Artist, *[]SongDTO, error) {
-	artist, err := s.repo.GetArtistById(artistId)
+	artist, err := s.repo.GetArtistById(s.db, artistId)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -110,29 +153,14 @@ func (s *artistService) GetArtistInformation(artistId uint) (*models.Artist, *[]
 
 	var empty_songs *[]SongDTO
 
-	songs, err := s.repo.GetArtistSongs(artist.ID)
+	songs, err := s.repo.GetArtistSongs(s.db, artist.ID)
 	if err != nil {
 		return nil, empty_songs, err
 	}
 
 	songDTOs := make([]SongDTO, 0, len(*songs))
 	for _, song := range *songs {
-		artists := make([]ArtistDTO, 0, len(song.Artists))
-
-		for _, songArtist := range song.Artists {
-			artist, err := s.repo.GetArtistById(songArtist.ArtistID)
-			if err != nil || artist == nil {
-				continue
-			}
-			artists = append(artists, ArtistDTO{artist.ID, artist.Name})
-		}
-
-		songDTO := SongDTO{
-			ID:      song.ID,
-			Title:   song.Title,
-			Artists: artists,
-		}
-		songDTOs = append(songDTOs, songDTO)
+		songDTOs = append(songDTOs, newSongDTO(&song, loadSongArtists(s.repo, s.db, song.Artists)))
 	}
 
 	return artist, &songDTOs, nil
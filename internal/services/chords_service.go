@@ -0,0 +1,37 @@
+package services
+
+import (
+	"chords_app/internal/chords"
+	"chords_app/internal/diagrams"
+	"errors"
+)
+
+type ChordService interface {
+	GetDiagramSVG(symbol, instrument, tuning string, variant uint) ([]byte, error)
+}
+
+type chordService struct{}
+
+func NewChordService() ChordService {
+	return &chordService{}
+}
+
+// GetDiagramSVG draws a voicing of the chord for the instrument in the
+// tuning, which is read like a song's tuning. Variant picks the voicing in
+// the order GetSongChords lists them, 0 being the easiest.
+func (s *chordService) GetDiagramSVG(symbol, instrument, tuning string, variant uint) ([]byte, error) {
+	chord, err := chords.ParseChord(symbol)
+	if err != nil {
+		return nil, errors.New("invalid chord")
+	}
+	diagramTuning, err := parseSongTuning(instrument, tuning)
+	if err != nil {
+		return nil, err
+	}
+
+	voicings := chords.Voicings(chord, diagramTuning, int(variant)+1)
+	if int(variant) >= len(voicings) {
+		return nil, errors.New("voicing not found")
+	}
+	return diagrams.SVG(chord.String(), voicings[variant], diagramTuning), nil
+}
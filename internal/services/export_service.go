@@ -0,0 +1,277 @@
+package services
+
+import (
+	"chords_app/internal/archive"
+	"chords_app/internal/chords"
+	"chords_app/internal/export"
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExportService interface {
+	ExportSong(songId uint, format string) (*export.File, error)
+	ExportSongs(w io.Writer, uploadedBy *uint) error
+	BackupFilename(uploadedBy *uint) string
+}
+
+// ErrInvalidFormat is returned by ExportSong for a format export does not
+// write.
+var ErrInvalidFormat = errors.New("invalid format, should be one of [" + strings.Join(formatNames(), ", ") + "]")
+
+// backupBatchSize is how many songs are read from the database at once
+// when exporting a backup.
+const backupBatchSize = 200
+
+// BackupManifest describes the songs of a backup archive, it is written
+// as manifest.json next to their ChordPro files.
+type BackupManifest struct {
+	Version    int          `json:"version"`
+	ExportedAt time.Time    `json:"exportedAt"`
+	UploadedBy *uint        `json:"uploadedBy,omitempty"`
+	Songs      []BackupSong `json:"songs"`
+}
+
+type BackupSong struct {
+	ID          uint           `json:"id"`
+	File        string         `json:"file"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Key         string         `json:"key"`
+	Mode        string         `json:"mode"`
+	Capo        uint           `json:"capo"`
+	Instrument  string         `json:"instrument"`
+	Tuning      string         `json:"tuning"`
+	TuningNotes string         `json:"tuningNotes"`
+	Tags        []string       `json:"tags"`
+	Artists     []BackupArtist `json:"artists"`
+	UploadedBy  uint           `json:"uploadedBy"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+}
+
+type BackupArtist struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ImageUrl    string `json:"imageUrl"`
+}
+
+const (
+	backupManifestVersion = 1
+	backupManifestPath    = "manifest.json"
+)
+
+type exportService struct {
+	songRepo   repositories.SongRepository
+	artistRepo repositories.ArtistRepository
+	db         *gorm.DB
+	now        func() time.Time
+}
+
+func NewExportService(
+	songRepo repositories.SongRepository,
+	artistRepo repositories.ArtistRepository,
+	db *gorm.DB,
+) ExportService {
+	return &exportService{songRepo, artistRepo, db, time.Now}
+}
+
+// ExportSong renders the stored song into a document. Content that is not
+// valid ChordPro returns chords.ParseErrors.
+func (s *exportService) ExportSong(songId uint, format string) (*export.File, error) {
+	exportFormat, err := export.ParseFormat(format)
+	if err != nil {
+		return nil, ErrInvalidFormat
+	}
+
+	song, err := s.songRepo.GetSongWithArtists(s.db, songId)
+	if err != nil {
+		return nil, err
+	}
+
+	exported, err := s.exportedSong(song)
+	if err != nil {
+		return nil, err
+	}
+	return export.Render(*exported, exportFormat)
+}
+
+// ExportSongs writes the songs uploaded by the user, or every song when
+// uploadedBy is nil, to w as a zip of ChordPro files laid out as
+// artist/title.cho with a manifest.json. The files carry their title and
+// artists as directives, and the import job reads the manifest for the
+// rest, so the archive restores the songs when imported back. Songs are
+// read in batches and written as they are read; only the manifest is
+// kept until the end.
+func (s *exportService) ExportSongs(w io.Writer, uploadedBy *uint) error {
+	exportedAt := s.now().UTC()
+	manifest := BackupManifest{
+		Version:    backupManifestVersion,
+		ExportedAt: exportedAt,
+		UploadedBy: uploadedBy,
+		Songs:      make([]BackupSong, 0),
+	}
+	writer := archive.NewZipWriter(w, exportedAt)
+	used := make(map[string]bool)
+
+	query := s.db
+	if uploadedBy != nil {
+		query = query.Where("uploaded_by = ?", *uploadedBy)
+	}
+	err := s.songRepo.FindSongsInBatches(query, backupBatchSize, func(songs *[]models.Song) error {
+		for _, song := range *songs {
+			entry := backupSong(&song)
+			entry.File = backupPath(entry, used)
+			if err := writer.Add(archive.File{Path: entry.File, Data: backupContent(&song, entry)}); err != nil {
+				return err
+			}
+			manifest.Songs = append(manifest.Songs, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writer.Add(archive.File{Path: backupManifestPath, Data: append(manifestData, '\n')}); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// BackupFilename names the archive ExportSongs writes.
+func (s *exportService) BackupFilename(uploadedBy *uint) string {
+	name := "chordhub-backup"
+	if uploadedBy != nil {
+		name += "-user-" + strconv.FormatUint(uint64(*uploadedBy), 10)
+	}
+	return name + "-" + s.now().UTC().Format("2006-01-02") + ".zip"
+}
+
+func backupSong(song *models.Song) BackupSong {
+	entry := BackupSong{
+		ID:          song.ID,
+		Title:       song.Title,
+		Description: song.Description,
+		Key:         song.Key,
+		Mode:        song.Mode,
+		Capo:        song.Capo,
+		Instrument:  song.Instrument,
+		Tuning:      song.Tuning,
+		TuningNotes: song.TuningNotes,
+		Tags:        make([]string, 0, len(song.Tags)),
+		Artists:     make([]BackupArtist, 0, len(song.Artists)),
+		UploadedBy:  song.UploadedBy,
+		CreatedAt:   song.CreatedAt.UTC(),
+		UpdatedAt:   song.UpdatedAt.UTC(),
+	}
+	for _, tag := range song.Tags {
+		entry.Tags = append(entry.Tags, tag.Name)
+	}
+	for _, songArtist := range song.Artists {
+		artist := songArtist.Artist
+		entry.Artists = append(entry.Artists, BackupArtist{artist.ID, artist.Name, artist.Description, artist.ImageUrl})
+	}
+	return entry
+}
+
+// backupPath places the song in the directory of its first artist,
+// numbering songs of the artist with the same file name.
+func backupPath(song BackupSong, used map[string]bool) string {
+	dir := "unknown-artist"
+	if len(song.Artists) > 0 {
+		dir = strings.TrimSuffix(export.Filename(song.Artists[0].Name, ""), ".")
+	}
+	name := strings.TrimSuffix(export.Filename(song.Title, "cho"), ".cho")
+
+	filePath := path.Join(dir, name+".cho")
+	for n := 2; used[filePath]; n++ {
+		filePath = path.Join(dir, name+"-"+strconv.Itoa(n)+".cho")
+	}
+	used[filePath] = true
+	return filePath
+}
+
+// backupContent writes the song as ChordPro with its title, artists,
+// capo and tuning. Content stored before it was validated is kept as is
+// rather than dropped from the backup.
+func backupContent(song *models.Song, entry BackupSong) []byte {
+	sheet, err := chords.ParseChordPro(song.Content)
+	if err != nil {
+		return []byte(song.Content)
+	}
+
+	names := make([]string, 0, len(entry.Artists))
+	for _, artist := range entry.Artists {
+		names = append(names, artist.Name)
+	}
+	instrument, tuning := exportedTuning(song)
+	return export.ChordPro(export.Song{
+		Title:      song.Title,
+		Artists:    names,
+		Key:        song.Key,
+		Capo:       song.Capo,
+		Instrument: instrument,
+		Tuning:     tuning,
+		Sheet:      sheet,
+	})
+}
+
+func (s *exportService) exportedSong(song *models.Song) (*export.Song, error) {
+	sheet, err := chords.ParseChordPro(song.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	artists := loadSongArtists(s.artistRepo, s.db, song.Artists)
+	names := make([]string, 0, len(artists))
+	for _, artist := range artists {
+		names = append(names, artist.Name)
+	}
+
+	instrument, tuning := exportedTuning(song)
+	return &export.Song{
+		Title:      song.Title,
+		Artists:    names,
+		Key:        song.Key,
+		Capo:       song.Capo,
+		Instrument: instrument,
+		Tuning:     tuning,
+		Sheet:      sheet,
+	}, nil
+}
+
+// exportedTuning returns the instrument and open strings to export the
+// song with, empty for guitar and for standard tuning.
+func exportedTuning(song *models.Song) (string, string) {
+	tuning := songTuning(song)
+	var instrument, notes string
+	if tuning.Instrument != chords.Guitar {
+		instrument = string(tuning.Instrument)
+	}
+	if tuning.Name != chords.StandardTuningName {
+		notes = tuning.Notes()
+	}
+	return instrument, notes
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(export.Formats))
+	for _, format := range export.Formats {
+		names = append(names, string(format))
+	}
+	return names
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"chords_app/internal/archive"
+	"chords_app/internal/chords"
+	"chords_app/internal/export"
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestExportSong_PDFListsArtistsInTitleOrder(t *testing.T) {
+	db, songService, artistService := setupSongServiceTest(t)
+	exportService := NewExportService(repositories.NewGormSongRepository(), repositories.NewGormArtistRepository(), db)
+
+	oasis, _ := artistService.CreateArtist("Oasis", "", "")
+	noel, _ := artistService.CreateArtist("Noel Gallagher", "", "")
+	song, _, err := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today", ArtistIds: []uint{noel.ID, oasis.ID}}, 1)
+	assert.NoError(t, err)
+
+	file, err := exportService.ExportSong(song.ID, "pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, "wonderwall.pdf", file.Filename)
+	assert.Equal(t, "application/pdf", file.ContentType)
+	sheet, _ := chords.ParseChordPro("[Em7]Today")
+	expected := export.PDF(export.Song{Title: "Wonderwall", Artists: []string{"Noel Gallagher", "Oasis"}, Key: song.Key, Sheet: sheet})
+	assert.Equal(t, expected, file.Data)
+
+	_, err = exportService.ExportSong(song.ID, "docx")
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+	assert.EqualError(t, err, "invalid format, should be one of [pdf, chordpro, text, html, markdown]")
+
+	_, err = exportService.ExportSong(song.ID+1, "pdf")
+	assert.EqualError(t, err, "song not found")
+}
+
+func TestExportSongs_BackupCanBeImported(t *testing.T) {
+	db, songService, artistService := setupSongServiceTest(t)
+	exportService := NewExportService(repositories.NewGormSongRepository(), repositories.NewGormArtistRepository(), db)
+
+	oasis, _ := artistService.CreateArtist("Oasis", "Rock band", "")
+	capo := uint(2)
+	_, _, err := songService.UploadSong(SongInput{Title: "Wonderwall", Description: "Acoustic", Content: "[Em7]Today", Capo: &capo, ArtistIds: []uint{oasis.ID}, Tags: []string{"britpop"}}, 1)
+	assert.NoError(t, err)
+	_, _, err = songService.UploadSong(SongInput{Title: "Wonderwall", Description: "Live", Content: "[Em7]Today is", ArtistIds: []uint{oasis.ID}}, 1)
+	assert.NoError(t, err)
+	_, _, err = songService.UploadSong(SongInput{Title: "Half the World Away", Content: "[C]I would like", ArtistIds: []uint{oasis.ID}}, 2)
+	assert.NoError(t, err)
+	// A song stored before content was validated.
+	legacy := models.Song{Title: "Whatever", Description: "Demo", Content: "{title: Whatever\n[C]Free", UploadedBy: 2, Key: "C", Mode: "major"}
+	assert.NoError(t, db.Create(&legacy).Error)
+	assert.NoError(t, db.Create(&models.SongArtist{SongID: legacy.ID, ArtistID: oasis.ID}).Error)
+
+	uploadedBy := uint(1)
+	var backup bytes.Buffer
+	assert.NoError(t, exportService.ExportSongs(&backup, &uploadedBy))
+	filename := exportService.BackupFilename(&uploadedBy)
+	assert.Regexp(t, `^chordhub-backup-user-1-\d{4}-\d{2}-\d{2}\.zip$`, filename)
+	assert.Regexp(t, `^chordhub-backup-\d{4}-\d{2}-\d{2}\.zip$`, exportService.BackupFilename(nil))
+
+	files, err := archive.Read(backup.Bytes())
+	assert.NoError(t, err)
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	assert.Equal(t, []string{"oasis/wonderwall.cho", "oasis/wonderwall-2.cho", "manifest.json"}, paths)
+	assert.Equal(t, "{title: Wonderwall}\n{artist: Oasis}\n{capo: 2}\n[Em7]Today\n", string(files[0].Data))
+
+	var manifest BackupManifest
+	assert.NoError(t, json.Unmarshal(files[2].Data, &manifest))
+	if assert.Len(t, manifest.Songs, 2) {
+		song := manifest.Songs[0]
+		assert.Equal(t, "oasis/wonderwall.cho", song.File)
+		assert.Equal(t, "Acoustic", song.Description)
+		assert.Equal(t, []string{"britpop"}, song.Tags)
+		assert.Equal(t, []BackupArtist{{oasis.ID, "Oasis", "Rock band", ""}}, song.Artists)
+		assert.False(t, song.CreatedAt.IsZero())
+	}
+
+	var all bytes.Buffer
+	assert.NoError(t, exportService.ExportSongs(&all, nil))
+	files, _ = archive.Read(all.Bytes())
+	assert.Len(t, files, 5, "expected every song without a user")
+	assert.Equal(t, "{title: Whatever\n[C]Free", string(files[3].Data), "expected invalid content to be kept as is")
+
+	// Restoring the backup into an empty database, where the first user
+	// does not exist.
+	importDB, importService, _, _ := setupImportServiceTest(t)
+	assert.NoError(t, importDB.Create(&models.User{Model: gorm.Model{ID: 2}, Name: "Noel"}).Error)
+	assert.NoError(t, importDB.Create(&models.User{Model: gorm.Model{ID: 3}, Name: "Admin"}).Error)
+	job, err := importService.CreateJob("chordhub-backup.zip", all.Bytes(), 3)
+	assert.NoError(t, err)
+	assert.NoError(t, importService.RunJob(job.ID))
+	report, _ := importService.GetJob(job.ID)
+	assert.Equal(t, uint(3), report.Counts[ImportFileImported])
+	assert.Equal(t, uint(1), report.Counts[ImportFileDuplicate], "expected the second recording to match the first by title")
+	assert.Equal(t, uint(1), report.Counts[ImportFileSkipped])
+
+	var restored []models.Song
+	importDB.Preload("Tags").Preload("Artists.Artist").Order("id").Find(&restored)
+	if assert.Len(t, restored, 3) {
+		wonderwall := restored[0]
+		assert.Equal(t, "Wonderwall", wonderwall.Title)
+		assert.Equal(t, "Acoustic", wonderwall.Description)
+		assert.Equal(t, uint(2), wonderwall.Capo)
+		assert.Equal(t, uint(3), wonderwall.UploadedBy, "expected songs of missing users to go to the importing user")
+		if assert.Len(t, wonderwall.Tags, 1) {
+			assert.Equal(t, "britpop", wonderwall.Tags[0].Name)
+		}
+		if assert.Len(t, wonderwall.Artists, 1) {
+			assert.Equal(t, "Rock band", wonderwall.Artists[0].Artist.Description)
+		}
+
+		assert.Equal(t, "Half the World Away", restored[1].Title)
+		assert.Equal(t, uint(2), restored[1].UploadedBy)
+
+		whatever := restored[2]
+		assert.Equal(t, "Whatever", whatever.Title)
+		assert.Equal(t, "Demo", whatever.Description)
+		assert.Equal(t, legacy.Content, whatever.Content)
+		assert.Equal(t, "C", whatever.Key)
+		assert.Len(t, whatever.Artists, 1)
+	}
+}
+
+func TestExportSongs_KeepsTuning(t *testing.T) {
+	db, songService, artistService := setupSongServiceTest(t)
+	exportService := NewExportService(repositories.NewGormSongRepository(), repositories.NewGormArtistRepository(), db)
+
+	artist, _ := artistService.CreateArtist("Foo Fighters", "", "")
+	_, _, err := songService.UploadSong(SongInput{Title: "Everlong", Content: "[D]Hello", Instrument: "bass", Tuning: "drop-d", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+
+	var backup bytes.Buffer
+	assert.NoError(t, exportService.ExportSongs(&backup, nil))
+	files, _ := archive.Read(backup.Bytes())
+	assert.Equal(t, "{title: Everlong}\n{artist: Foo Fighters}\n{instrument: bass}\n{tuning: D A D G}\n[D]Hello\n", string(files[0].Data))
+
+	var manifest BackupManifest
+	assert.NoError(t, json.Unmarshal(files[1].Data, &manifest))
+	assert.Equal(t, "drop-d", manifest.Songs[0].Tuning)
+
+	importDB, importService, _, _ := setupImportServiceTest(t)
+	job, _ := importService.CreateJob("backup.zip", backup.Bytes(), 1)
+	assert.NoError(t, importService.RunJob(job.ID))
+
+	var song models.Song
+	assert.NoError(t, importDB.First(&song).Error)
+	assert.Equal(t, "bass", song.Instrument)
+	assert.Equal(t, "drop-d", song.Tuning)
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"chords_app/internal/adapters/opensearch"
+	"chords_app/internal/archive"
+	"chords_app/internal/chords"
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Outcomes of importing a file.
+const (
+	ImportFileImported  = "imported"
+	ImportFileDuplicate = "duplicate"
+	ImportFileInvalid   = "invalid"
+	ImportFileSkipped   = "skipped"
+)
+
+const (
+	importPollInterval = 5 * time.Second
+	// importJobLease is how long a running job may go without importing a
+	// file before it is taken to be interrupted and run again.
+	importJobLease = 2 * time.Minute
+)
+
+// ErrInvalidArchive is returned by CreateJob for data archive.Read cannot
+// read.
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// importExtensions are the file extensions read as songs, ChordPro or
+// chords-over-lyrics text.
+var importExtensions = map[string]bool{
+	".cho": true, ".chordpro": true, ".chopro": true, ".crd": true, ".pro": true, ".txt": true,
+}
+
+type ImportJobDTO struct {
+	ID       uint
+	Filename string
+	Status   string
+	Error    string
+	Counts   map[string]uint
+	Files    []ImportFileDTO
+}
+
+type ImportFileDTO struct {
+	Path    string
+	Status  string
+	SongID  uint
+	Message string
+}
+
+type ImportService interface {
+	CreateJob(filename string, data []byte, uploadedBy uint) (*ImportJobDTO, error)
+	GetJob(jobId uint) (*ImportJobDTO, error)
+	RunJob(jobId uint) error
+	RunUnfinishedJobs() (int, error)
+}
+
+type importService struct {
+	jobRepo    repositories.ImportJobRepository
+	songRepo   repositories.SongRepository
+	artistRepo repositories.ArtistRepository
+	userRepo   repositories.UserRepository
+	outboxRepo repositories.OutboxRepository
+	db         *gorm.DB
+}
+
+func NewImportService(
+	jobRepo repositories.ImportJobRepository,
+	songRepo repositories.SongRepository,
+	artistRepo repositories.ArtistRepository,
+	userRepo repositories.UserRepository,
+	outboxRepo repositories.OutboxRepository,
+	db *gorm.DB,
+) ImportService {
+	return &importService{jobRepo, songRepo, artistRepo, userRepo, outboxRepo, db}
+}
+
+// CreateJob stores the archive as a pending job for RunJob to import.
+func (s *importService) CreateJob(filename string, data []byte, uploadedBy uint) (*ImportJobDTO, error) {
+	if _, err := archive.Read(data); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+
+	job := models.ImportJob{
+		Filename:   filename,
+		Archive:    data,
+		Status:     repositories.ImportJobPending,
+		UploadedBy: uploadedBy,
+	}
+	if err := s.jobRepo.CreateJob(s.db, &job); err != nil {
+		return nil, err
+	}
+	return importJobToDTO(&job), nil
+}
+
+// GetJob reports the job status and the outcome of every file imported
+// so far.
+func (s *importService) GetJob(jobId uint) (*ImportJobDTO, error) {
+	job, err := s.jobRepo.GetJobWithFiles(s.db, jobId)
+	if err != nil {
+		return nil, err
+	}
+	return importJobToDTO(job), nil
+}
+
+// RunJob imports the files of the archive that have no outcome yet. Songs
+// of a backup written by ExportSongs are restored with the description,
+// tags and uploader of its manifest. Every
+// file is imported in its own transaction together with its outcome, so
+// a job interrupted by a crash or a database error can be run again and
+// continues with the next file. A job another worker is running is left
+// alone until its heartbeat is older than importJobLease.
+func (s *importService) RunJob(jobId uint) error {
+	job, err := s.jobRepo.GetJob(s.db, jobId)
+	if err != nil {
+		return err
+	}
+	if job.Status == repositories.ImportJobDone || job.Status == repositories.ImportJobFailed {
+		return nil
+	}
+
+	now := time.Now()
+	claimed, err := s.jobRepo.ClaimJob(s.db, job.ID, now, now.Add(-importJobLease))
+	if err != nil || !claimed {
+		// Another worker is running the job.
+		return err
+	}
+	job.Status, job.HeartbeatAt = repositories.ImportJobRunning, now
+
+	files, err := archive.Read(job.Archive)
+	if err != nil {
+		// Reading the archive again fails the same way, so the job is
+		// not run again.
+		job.Status, job.Error = repositories.ImportJobFailed, err.Error()
+		job.Archive = nil
+		return s.jobRepo.UpdateJob(s.db, job)
+	}
+
+	imported, err := s.jobRepo.GetImportedPaths(s.db, job.ID)
+	if err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(imported))
+	for _, path := range imported {
+		done[path] = true
+	}
+
+	backup := backupEntries(files)
+	for _, file := range files {
+		if done[file.Path] {
+			continue
+		}
+		if err := s.importFile(job, file, backup[file.Path]); err != nil {
+			return err
+		}
+		done[file.Path] = true
+	}
+
+	job.Status = repositories.ImportJobDone
+	job.Archive = nil
+	return s.jobRepo.UpdateJob(s.db, job)
+}
+
+// RunUnfinishedJobs runs pending and interrupted jobs and returns how many
+// finished. A job that stops on an error is logged and left running, so
+// it does not hold up the jobs after it and is run again once its lease
+// expires.
+func (s *importService) RunUnfinishedJobs() (int, error) {
+	ids, err := s.jobRepo.GetUnfinishedJobIds(s.db, time.Now().Add(-importJobLease))
+	if err != nil {
+		return 0, err
+	}
+
+	finished := 0
+	for _, id := range ids {
+		if err := s.RunJob(id); err != nil {
+			slog.Error("import job stopped", slog.Uint64("id", uint64(id)), slog.String("error", err.Error()))
+			continue
+		}
+		finished++
+	}
+	return finished, nil
+}
+
+// RunImportJobs runs unfinished import jobs until ctx is cancelled,
+// picking up jobs interrupted by a restart.
+func RunImportJobs(ctx context.Context, service ImportService) {
+	ticker := time.NewTicker(importPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := service.RunUnfinishedJobs(); err != nil {
+			slog.Error("failed to run import jobs", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *importService) importFile(job *models.ImportJob, file archive.File, backup *BackupSong) error {
+	uploadedBy, err := s.backupUploader(backup, job.UploadedBy)
+	if err != nil {
+		return err
+	}
+
+	tx := s.db.Begin()
+
+	outcome, err := s.importSong(tx, uploadedBy, file, backup)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	outcome.ImportJobID = job.ID
+	outcome.Path = file.Path
+
+	if err := s.jobRepo.AddFile(tx, outcome); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.jobRepo.Heartbeat(tx, job.ID, time.Now()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// importSong creates the song of a file, and its artists when there are
+// none with the same name. Problems with the file are reported in the
+// outcome, the error is only set when the database fails. A backup entry
+// names the title and artists and adds the rest of the song, and restores
+// content backed up as is because it was not valid ChordPro.
+func (s *importService) importSong(tx *gorm.DB, uploadedBy uint, file archive.File, backup *BackupSong) (*models.ImportJobFile, error) {
+	name := path.Base(file.Path)
+	if strings.HasPrefix(name, ".") || strings.Contains(file.Path, "__MACOSX/") ||
+		!importExtensions[strings.ToLower(path.Ext(name))] {
+		return &models.ImportJobFile{Status: ImportFileSkipped, Message: "not a song file"}, nil
+	}
+	if file.Err != nil {
+		return &models.ImportJobFile{Status: ImportFileInvalid, Message: file.Err.Error()}, nil
+	}
+	if !utf8.Valid(file.Data) {
+		return &models.ImportJobFile{Status: ImportFileInvalid, Message: "file is not UTF-8 text"}, nil
+	}
+
+	text := strings.TrimPrefix(string(file.Data), "\uFEFF")
+	content, _ := importContent(text)
+	song := models.Song{Content: content, UploadedBy: uploadedBy}
+	artists := make([]models.Artist, 0)
+
+	metadata, err := parseSongMetadata(content, "", "", nil, chords.StandardGuitar)
+	switch {
+	case err == nil:
+		song.Title = strings.TrimSpace(metadata.sheet.Title())
+		setSongTuning(&song, metadata.tuning)
+		if metadata.capo != nil {
+			song.Capo = *metadata.capo
+		}
+		detectSongKey(&song)
+		for _, artistName := range importArtistNames(metadata.sheet, file.Path) {
+			artists = append(artists, models.Artist{Name: artistName})
+		}
+	case backup != nil:
+		song.Content = text
+		song.Capo, song.Key, song.Mode = backup.Capo, backup.Key, backup.Mode
+		song.Instrument, song.Tuning, song.TuningNotes = backup.Instrument, backup.Tuning, backup.TuningNotes
+	default:
+		return &models.ImportJobFile{Status: ImportFileInvalid, Message: err.Error()}, nil
+	}
+
+	if backup != nil {
+		restoreBackup(&song, &artists, backup)
+	}
+	if song.Title == "" {
+		song.Title = strings.TrimSuffix(name, path.Ext(name))
+	}
+	if len(artists) == 0 {
+		return &models.ImportJobFile{Status: ImportFileInvalid, Message: "no artist, add an {artist} directive"}, nil
+	}
+
+	mainArtist, err := s.artistRepo.GetArtistByName(tx, artists[0].Name)
+	if err != nil {
+		return nil, err
+	}
+	if mainArtist != nil {
+		existing, err := s.songRepo.GetSongByTitleAndArtist(tx, song.Title, mainArtist.ID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return &models.ImportJobFile{Status: ImportFileDuplicate, SongID: existing.ID, Message: "song already exists"}, nil
+		}
+	}
+
+	if err := s.songRepo.CreateSong(tx, &song); err != nil {
+		return nil, err
+	}
+	if backup != nil {
+		if err := s.songRepo.SetSongTags(tx, &song, normalizeTags(backup.Tags)); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, newArtist := range artists {
+		artist, err := s.findOrCreateArtist(tx, newArtist)
+		if err != nil {
+			return nil, err
+		}
+		songArtist := models.SongArtist{ArtistID: artist.ID, SongID: song.ID, TitleOrder: i}
+		if err := s.songRepo.AttachAuthor(tx, &songArtist); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.outboxRepo.Enqueue(tx, repositories.OutboxIndex, opensearch.SongType, song.ID); err != nil {
+		return nil, err
+	}
+	return &models.ImportJobFile{Status: ImportFileImported, SongID: song.ID}, nil
+}
+
+// restoreBackup sets the title, artists and description of the backup
+// entry.
+func restoreBackup(song *models.Song, artists *[]models.Artist, backup *BackupSong) {
+	if backup.Title != "" {
+		song.Title = backup.Title
+	}
+	if len(backup.Artists) > 0 {
+		*artists = (*artists)[:0]
+		for _, artist := range backup.Artists {
+			*artists = append(*artists, models.Artist{Name: artist.Name, Description: artist.Description, ImageUrl: artist.ImageUrl})
+		}
+	}
+	song.Description = backup.Description
+}
+
+// backupUploader returns the user who uploaded the backed up song, or
+// uploadedBy when there is no backup entry or no such user.
+func (s *importService) backupUploader(backup *BackupSong, uploadedBy uint) (uint, error) {
+	if backup == nil || backup.UploadedBy == 0 {
+		return uploadedBy, nil
+	}
+	user, err := s.userRepo.FindById(backup.UploadedBy)
+	if err != nil || user == nil {
+		return uploadedBy, err
+	}
+	return user.ID, nil
+}
+
+// findOrCreateArtist returns the artist with the same name, or creates
+// the given one.
+func (s *importService) findOrCreateArtist(tx *gorm.DB, newArtist models.Artist) (*models.Artist, error) {
+	artist, err := s.artistRepo.GetArtistByName(tx, newArtist.Name)
+	if err != nil || artist != nil {
+		return artist, err
+	}
+
+	artist = &newArtist
+	if err := s.artistRepo.CreateArtist(tx, artist); err != nil {
+		return nil, err
+	}
+	if err := s.outboxRepo.Enqueue(tx, repositories.OutboxIndex, opensearch.ArtistType, artist.ID); err != nil {
+		return nil, err
+	}
+	return artist, nil
+}
+
+// backupEntries reads the manifest of a backup written by ExportSongs,
+// keyed by file. Archives without a manifest import from their files
+// alone.
+func backupEntries(files []archive.File) map[string]*BackupSong {
+	entries := make(map[string]*BackupSong)
+	for _, file := range files {
+		if file.Path != backupManifestPath || file.Err != nil {
+			continue
+		}
+		var manifest BackupManifest
+		if err := json.Unmarshal(file.Data, &manifest); err != nil || manifest.Version != backupManifestVersion {
+			slog.Warn("ignoring unreadable backup manifest", slog.String("path", file.Path))
+			continue
+		}
+		for i := range manifest.Songs {
+			entries[manifest.Songs[i].File] = &manifest.Songs[i]
+		}
+	}
+	return entries
+}
+
+// importArtistNames returns the {artist} directives of the sheet, or the
+// directory the file is in for songbooks organised as Artist/Song.cho.
+func importArtistNames(sheet *chords.Sheet, filePath string) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range sheet.DirectiveValues("artist") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		names = append(names, name)
+	}
+
+	if dir := path.Base(path.Dir(filePath)); len(names) == 0 && dir != "." && dir != "/" {
+		names = append(names, dir)
+	}
+	return names
+}
+
+func importJobToDTO(job *models.ImportJob) *ImportJobDTO {
+	dto := ImportJobDTO{
+		ID:       job.ID,
+		Filename: job.Filename,
+		Status:   job.Status,
+		Error:    job.Error,
+		Counts:   map[string]uint{},
+		Files:    make([]ImportFileDTO, 0, len(job.Files)),
+	}
+	for _, status := range []string{ImportFileImported, ImportFileDuplicate, ImportFileInvalid, ImportFileSkipped} {
+		dto.Counts[status] = 0
+	}
+	for _, file := range job.Files {
+		dto.Counts[file.Status]++
+		dto.Files = append(dto.Files, ImportFileDTO{file.Path, file.Status, file.SongID, file.Message})
+	}
+	return &dto
+}
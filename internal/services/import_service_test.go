@@ -0,0 +1,249 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupImportServiceTest(t *testing.T) (*gorm.DB, ImportService, SongService, ArtistService) {
+	db, songService, artistService := setupSongServiceTest(t)
+	importService := NewImportService(
+		repositories.NewGormImportJobRepository(),
+		repositories.NewGormSongRepository(),
+		repositories.NewGormArtistRepository(),
+		repositories.NewGormUserRepository(db),
+		repositories.NewGormOutboxRepository(),
+		db,
+	)
+	return db, importService, songService, artistService
+}
+
+func zipFiles(t *testing.T, files [][2]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, file := range files {
+		f, err := w.Create(file[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(file[1]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImportJob(t *testing.T) {
+	db, importService, songService, artistService := setupImportServiceTest(t)
+	oasis, _ := artistService.CreateArtist("Oasis", "", "")
+	_, _, err := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today", ArtistIds: []uint{oasis.ID}}, 1)
+	assert.NoError(t, err)
+
+	data := zipFiles(t, [][2]string{
+		{"oasis/wonderwall.cho", "{title: wonderwall}\n{artist: OASIS}\n[Em7]Today"},
+		{"Queen/Bohemian Rhapsody.cho", "{capo: 2}\n[Bb]Is this the real life"},
+		{"duet.cho", "{title: Under Pressure}\n{artist: Queen}\n{artist: David Bowie}\n[D]Pressure"},
+		{"broken.cho", "{title: Broken}\n{artist: Oasis}\n[Xyz]la"},
+		{"orphan.cho", "{title: Orphan}\n[C]la"},
+		{"README.md", "# Songbook"},
+	})
+	job, err := importService.CreateJob("songbook.zip", data, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, repositories.ImportJobPending, job.Status)
+
+	assert.NoError(t, importService.RunJob(job.ID))
+
+	report, err := importService.GetJob(job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, repositories.ImportJobDone, report.Status)
+	assert.Equal(t, map[string]uint{"imported": 2, "duplicate": 1, "invalid": 2, "skipped": 1}, report.Counts)
+
+	statuses := make(map[string]string)
+	for _, file := range report.Files {
+		statuses[file.Path] = file.Status
+	}
+	assert.Equal(t, map[string]string{
+		"oasis/wonderwall.cho":        ImportFileDuplicate,
+		"Queen/Bohemian Rhapsody.cho": ImportFileImported,
+		"duet.cho":                    ImportFileImported,
+		"broken.cho":                  ImportFileInvalid,
+		"orphan.cho":                  ImportFileInvalid,
+		"README.md":                   ImportFileSkipped,
+	}, statuses)
+
+	var queen []models.Artist
+	db.Where("name = ?", "Queen").Find(&queen)
+	assert.Len(t, queen, 1, "expected artists to be created once")
+
+	var rhapsody models.Song
+	db.Preload("Artists").Where("title = ?", "Bohemian Rhapsody").First(&rhapsody)
+	assert.Equal(t, uint(2), rhapsody.Capo)
+	assert.Len(t, rhapsody.Artists, 1)
+
+	var duet models.Song
+	db.Preload("Artists", func(db *gorm.DB) *gorm.DB { return db.Order("title_order") }).
+		Where("title = ?", "Under Pressure").First(&duet)
+	if assert.Len(t, duet.Artists, 2) {
+		assert.Equal(t, queen[0].ID, duet.Artists[0].ArtistID)
+	}
+
+	var stored models.ImportJob
+	db.First(&stored, job.ID)
+	assert.Empty(t, stored.Archive, "expected the archive to be dropped when the job is done")
+}
+
+func TestImportJob_Resume(t *testing.T) {
+	db, importService, _, _ := setupImportServiceTest(t)
+
+	data := zipFiles(t, [][2]string{
+		{"Queen/one.cho", "[C]One"},
+		{"Queen/two.cho", "[C]Two"},
+	})
+	job, err := importService.CreateJob("songbook.zip", data, 1)
+	assert.NoError(t, err)
+
+	// A job interrupted after importing its first file.
+	db.Model(&models.ImportJob{}).Where("id = ?", job.ID).Update("status", repositories.ImportJobRunning)
+	db.Create(&models.ImportJobFile{ImportJobID: job.ID, Path: "Queen/one.cho", Status: ImportFileImported})
+
+	finished, err := importService.RunUnfinishedJobs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+
+	report, _ := importService.GetJob(job.ID)
+	assert.Equal(t, repositories.ImportJobDone, report.Status)
+	assert.Len(t, report.Files, 2)
+
+	var titles []string
+	db.Model(&models.Song{}).Pluck("title", &titles)
+	assert.Equal(t, []string{"two"}, titles)
+}
+
+func TestImportJob_LeavesJobsRunningElsewhere(t *testing.T) {
+	db, importService, _, _ := setupImportServiceTest(t)
+
+	job, err := importService.CreateJob("songbook.zip", zipFiles(t, [][2]string{{"Queen/one.cho", "[C]One"}}), 1)
+	assert.NoError(t, err)
+	db.Model(&models.ImportJob{}).Where("id = ?", job.ID).
+		Updates(map[string]interface{}{"status": repositories.ImportJobRunning, "heartbeat_at": time.Now()})
+
+	finished, err := importService.RunUnfinishedJobs()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, finished)
+	assert.NoError(t, importService.RunJob(job.ID))
+
+	var count int64
+	db.Model(&models.Song{}).Count(&count)
+	assert.Zero(t, count, "expected a job with a recent heartbeat not to be run twice")
+
+	// The worker running it stopped.
+	db.Model(&models.ImportJob{}).Where("id = ?", job.ID).Update("heartbeat_at", time.Now().Add(-2*importJobLease))
+	finished, err = importService.RunUnfinishedJobs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+}
+
+func TestImportJob_ClaimsJobOnce(t *testing.T) {
+	db, _, _, _ := setupImportServiceTest(t)
+	jobRepo := repositories.NewGormImportJobRepository()
+
+	job := models.ImportJob{Status: repositories.ImportJobPending}
+	assert.NoError(t, jobRepo.CreateJob(db, &job))
+
+	now := time.Now()
+	claimed, err := jobRepo.ClaimJob(db, job.ID, now, now.Add(-importJobLease))
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = jobRepo.ClaimJob(db, job.ID, now, now.Add(-importJobLease))
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+}
+
+// failingJobRepository fails to load the imported paths of one job.
+type failingJobRepository struct {
+	repositories.ImportJobRepository
+	jobId uint
+}
+
+func (r *failingJobRepository) GetImportedPaths(db *gorm.DB, jobId uint) ([]string, error) {
+	if jobId == r.jobId {
+		return nil, errors.New("connection reset")
+	}
+	return r.ImportJobRepository.GetImportedPaths(db, jobId)
+}
+
+func TestRunUnfinishedJobs_StoppedJobDoesNotBlockOthers(t *testing.T) {
+	db, importService, _, _ := setupImportServiceTest(t)
+
+	first, err := importService.CreateJob("one.zip", zipFiles(t, [][2]string{{"Queen/one.cho", "[C]One"}}), 1)
+	assert.NoError(t, err)
+	second, err := importService.CreateJob("two.zip", zipFiles(t, [][2]string{{"Queen/two.cho", "[C]Two"}}), 1)
+	assert.NoError(t, err)
+
+	failingService := NewImportService(
+		&failingJobRepository{repositories.NewGormImportJobRepository(), first.ID},
+		repositories.NewGormSongRepository(),
+		repositories.NewGormArtistRepository(),
+		repositories.NewGormUserRepository(db),
+		repositories.NewGormOutboxRepository(),
+		db,
+	)
+	finished, err := failingService.RunUnfinishedJobs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+
+	report, _ := importService.GetJob(first.ID)
+	assert.Equal(t, repositories.ImportJobRunning, report.Status, "expected a database error not to fail the job")
+	report, _ = importService.GetJob(second.ID)
+	assert.Equal(t, repositories.ImportJobDone, report.Status)
+
+	finished, err = importService.RunUnfinishedJobs()
+	assert.NoError(t, err)
+	assert.Zero(t, finished, "expected the job to be left alone until its lease expires")
+
+	db.Model(&models.ImportJob{}).Where("id = ?", first.ID).Update("heartbeat_at", time.Now().Add(-2*importJobLease))
+	finished, err = importService.RunUnfinishedJobs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, finished)
+	report, _ = importService.GetJob(first.ID)
+	assert.Equal(t, repositories.ImportJobDone, report.Status)
+}
+
+func TestRunJob_FailsUnreadableArchive(t *testing.T) {
+	db, importService, _, _ := setupImportServiceTest(t)
+
+	job := models.ImportJob{Filename: "songs.zip", Archive: []byte("PK\x03\x04truncated"), Status: repositories.ImportJobPending}
+	assert.NoError(t, repositories.NewGormImportJobRepository().CreateJob(db, &job))
+
+	assert.NoError(t, importService.RunJob(job.ID))
+	report, _ := importService.GetJob(job.ID)
+	assert.Equal(t, repositories.ImportJobFailed, report.Status)
+	assert.NotEmpty(t, report.Error)
+
+	var stored models.ImportJob
+	db.First(&stored, job.ID)
+	assert.Empty(t, stored.Archive, "expected the archive of a failed job to be dropped")
+
+	finished, err := importService.RunUnfinishedJobs()
+	assert.NoError(t, err)
+	assert.Zero(t, finished, "expected the failed job not to be run again")
+}
+
+func TestCreateImportJob_InvalidArchive(t *testing.T) {
+	_, importService, _, _ := setupImportServiceTest(t)
+
+	_, err := importService.CreateJob("songs.rar", []byte("not an archive"), 1)
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+	assert.EqualError(t, err, "invalid archive: unknown archive format, should be zip, tar or tar.gz")
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"chords_app/internal/adapters/opensearch"
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	dispatchPollInterval = 2 * time.Second
+	dispatchBatchSize    = 100
+	dispatchBaseBackoff  = time.Second
+	dispatchMaxBackoff   = 10 * time.Minute
+)
+
+// IndexDispatcher delivers search outbox entries to the search adapter.
+// Entries only carry the object type and ID, the current row is loaded at
+// delivery time so retried or reordered entries still converge on the
+// database state.
+type IndexDispatcher struct {
+	outboxRepo repositories.OutboxRepository
+	songRepo   repositories.SongRepository
+	artistRepo repositories.ArtistRepository
+	osAdapter  opensearch.SearchAdapter
+	db         *gorm.DB
+	now        func() time.Time
+}
+
+func NewIndexDispatcher(
+	outboxRepo repositories.OutboxRepository,
+	songRepo repositories.SongRepository,
+	artistRepo repositories.ArtistRepository,
+	osAdapter opensearch.SearchAdapter,
+	db *gorm.DB,
+) *IndexDispatcher {
+	return &IndexDispatcher{outboxRepo, songRepo, artistRepo, osAdapter, db, time.Now}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (d *IndexDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchDue(); err != nil {
+			slog.Error("failed to dispatch search outbox", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue delivers every entry whose next attempt is due and returns
+// the number of entries delivered successfully.
+func (d *IndexDispatcher) DispatchDue() (int, error) {
+	entries, err := d.outboxRepo.GetDue(d.db, d.now(), dispatchBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, entry := range *entries {
+		if err := d.deliver(&entry); err != nil {
+			backoff := dispatchBackoff(entry.Attempts)
+			slog.Warn(
+				"search outbox delivery failed",
+				slog.Uint64("entry", uint64(entry.ID)),
+				slog.Uint64("attempts", uint64(entry.Attempts+1)),
+				slog.Duration("retryIn", backoff),
+				slog.String("error", err.Error()),
+			)
+			if err := d.outboxRepo.Reschedule(d.db, &entry, d.now().Add(backoff), err.Error()); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+
+		if err := d.outboxRepo.Complete(d.db, &entry); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+func (d *IndexDispatcher) deliver(entry *models.SearchOutbox) error {
+	switch entry.ObjType {
+	case opensearch.SongType:
+		if entry.Operation == repositories.OutboxDelete {
+			return d.osAdapter.DeleteSong(entry.ObjID)
+		}
+
+		song, err := d.songRepo.GetSongWithArtists(d.db, entry.ObjID)
+		if err != nil {
+			if err.Error() == "song not found" {
+				return d.osAdapter.DeleteSong(entry.ObjID)
+			}
+			return err
+		}
+		return d.osAdapter.IndexSong(song, loadSongArtists(d.artistRepo, d.db, song.Artists))
+	case opensearch.ArtistType:
+		if entry.Operation == repositories.OutboxDelete {
+			return d.osAdapter.DeleteArtist(entry.ObjID)
+		}
+
+		artist, err := d.artistRepo.GetArtistById(d.db, entry.ObjID)
+		if err != nil {
+			return err
+		}
+		if artist == nil {
+			return d.osAdapter.DeleteArtist(entry.ObjID)
+		}
+		return d.osAdapter.IndexArtist(artist)
+	default:
+		return fmt.Errorf("unknown search object type %q", entry.ObjType)
+	}
+}
+
+func dispatchBackoff(attempts uint) time.Duration {
+	backoff := dispatchBaseBackoff
+	for i := uint(0); i < attempts && backoff < dispatchMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > dispatchMaxBackoff {
+		return dispatchMaxBackoff
+	}
+	return backoff
+}
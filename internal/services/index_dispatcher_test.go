@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"chords_app/internal/adapters/opensearch"
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type flakyAdapter struct {
+	*opensearch.InMemoryAdapter
+	failures int
+}
+
+func (a *flakyAdapter) IndexSong(song *models.Song, artists []models.Artist) error {
+	if a.failures > 0 {
+		a.failures--
+		return errors.New("cluster unavailable")
+	}
+	return a.InMemoryAdapter.IndexSong(song, artists)
+}
+
+func setupDispatcherTest(t *testing.T, failures int) (*gorm.DB, SongService, ArtistService, *IndexDispatcher, *flakyAdapter) {
+	db := newTestDB(t)
+	adapter := &flakyAdapter{opensearch.NewInMemoryAdapter(), failures}
+	outboxRepo := repositories.NewGormOutboxRepository()
+	songRepo := repositories.NewGormSongRepository()
+	artistRepo := repositories.NewGormArtistRepository()
+
+	songService := NewSongService(songRepo, artistRepo, outboxRepo, db)
+	artistService := NewArtistService(artistRepo, outboxRepo, db)
+	dispatcher := NewIndexDispatcher(outboxRepo, songRepo, artistRepo, adapter, db)
+
+	return db, songService, artistService, dispatcher, adapter
+}
+
+func TestIndexDispatcher_DeliversOutbox(t *testing.T) {
+	db, songService, artistService, dispatcher, adapter := setupDispatcherTest(t, 0)
+
+	artist, err := artistService.CreateArtist("Oasis", "", "")
+	assert.NoError(t, err)
+	_, _, err = songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today is gonna be the day", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+
+	results, _ := adapter.Search("wonderwall oasis", opensearch.SearchFilters{}, 10, 0)
+	assert.Empty(t, results.Hits, "expected nothing indexed before dispatch")
+
+	delivered, err := dispatcher.DispatchDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+
+	results, _ = adapter.Search("wonderwall oasis", opensearch.SearchFilters{}, 10, 0)
+	assert.Len(t, results.Hits, 2, "expected song and artist to be indexed")
+
+	var pending int64
+	db.Model(&models.SearchOutbox{}).Count(&pending)
+	assert.Zero(t, pending, "expected delivered entries to be removed")
+}
+
+func TestIndexDispatcher_RetriesWithBackoff(t *testing.T) {
+	db, songService, artistService, dispatcher, adapter := setupDispatcherTest(t, 1)
+
+	artist, _ := artistService.CreateArtist("Kino", "", "")
+	song, _, err := songService.UploadSong(SongInput{Title: "Gruppa Krovi", Content: "[Am]Teplo", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	dispatcher.now = func() time.Time { return now }
+
+	delivered, err := dispatcher.DispatchDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered, "expected only the artist to be delivered")
+
+	var entry models.SearchOutbox
+	db.Where("obj_type = ? AND obj_id = ?", opensearch.SongType, song.ID).First(&entry)
+	assert.Equal(t, uint(1), entry.Attempts)
+	assert.Equal(t, "cluster unavailable", entry.LastError)
+	assert.True(t, entry.NextAttemptAt.After(now), "expected retry to be scheduled in the future")
+
+	delivered, _ = dispatcher.DispatchDue()
+	assert.Zero(t, delivered, "expected retry to wait for backoff")
+
+	now = now.Add(dispatchBackoff(0))
+	delivered, err = dispatcher.DispatchDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+
+	results, _ := adapter.Search("krovi", opensearch.SearchFilters{}, 10, 0)
+	assert.Len(t, results.Hits, 1, "expected song to be indexed after retry")
+}
+
+func TestIndexDispatcher_DeletesRemovedSongs(t *testing.T) {
+	_, songService, artistService, dispatcher, adapter := setupDispatcherTest(t, 0)
+
+	artist, _ := artistService.CreateArtist("Kino", "", "")
+	song, _, _ := songService.UploadSong(SongInput{Title: "Zvezda po imeni Solntse", Content: "[Am]Belyy sneg", ArtistIds: []uint{artist.ID}}, 1)
+	dispatcher.DispatchDue()
+
+	assert.NoError(t, songService.DeleteSong(song.ID))
+	dispatcher.DispatchDue()
+
+	results, _ := adapter.Search("solntse", opensearch.SearchFilters{}, 10, 0)
+	assert.Empty(t, results.Hits, "expected song document to be deleted")
+}
+
+func TestDispatchBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, dispatchBackoff(0))
+	assert.Equal(t, 4*time.Second, dispatchBackoff(2))
+	assert.Equal(t, dispatchMaxBackoff, dispatchBackoff(100))
+}
+
+// searchHits returns the type and id of every hit, like "song_1".
+func searchHits(t *testing.T, adapter opensearch.SearchAdapter, query string, filters opensearch.SearchFilters) []string {
+	results, err := adapter.Search(query, filters, 10, 0)
+	assert.NoError(t, err)
+	hits := make([]string, 0, len(results.Hits))
+	for _, hit := range results.Hits {
+		hits = append(hits, hit.ObjType+"_"+strconv.FormatUint(uint64(hit.ObjId), 10))
+	}
+	sort.Strings(hits)
+	return hits
+}
+
+func TestIndexSync_RenamedArtistReindexesSongs(t *testing.T) {
+	_, songService, artistService, dispatcher, adapter := setupDispatcherTest(t, 0)
+
+	artist, _ := artistService.CreateArtist("Oasis", "", "")
+	song, _, _ := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today", ArtistIds: []uint{artist.ID}}, 1)
+	dispatcher.DispatchDue()
+
+	_, err := artistService.UpdateArtist(artist.ID, "Noel Gallagher", "", "")
+	assert.NoError(t, err)
+	delivered, err := dispatcher.DispatchDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, delivered, "expected the artist and its song to be reindexed")
+
+	assert.Empty(t, searchHits(t, adapter, "oasis", opensearch.SearchFilters{}), "expected the old name to be gone")
+	assert.Equal(t,
+		[]string{fmt.Sprintf("artist_%d", artist.ID), fmt.Sprintf("song_%d", song.ID)},
+		searchHits(t, adapter, "gallagher", opensearch.SearchFilters{}))
+}
+
+func TestIndexSync_DetachedArtistLeavesSong(t *testing.T) {
+	_, songService, artistService, dispatcher, adapter := setupDispatcherTest(t, 0)
+
+	oasis, _ := artistService.CreateArtist("Oasis", "", "")
+	noel, _ := artistService.CreateArtist("Noel Gallagher", "", "")
+	song, _, _ := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today", ArtistIds: []uint{oasis.ID, noel.ID}}, 1)
+	dispatcher.DispatchDue()
+	songHit := []string{fmt.Sprintf("song_%d", song.ID)}
+	assert.Equal(t, songHit, searchHits(t, adapter, "", opensearch.SearchFilters{ArtistIds: []uint{oasis.ID}}))
+
+	_, _, err := songService.UpdateSong(song.ID, SongInput{ArtistIds: []uint{noel.ID}})
+	assert.NoError(t, err)
+	dispatcher.DispatchDue()
+
+	assert.Empty(t, searchHits(t, adapter, "", opensearch.SearchFilters{ArtistIds: []uint{oasis.ID}}))
+	assert.Equal(t, songHit, searchHits(t, adapter, "", opensearch.SearchFilters{ArtistIds: []uint{noel.ID}}))
+}
+
+func TestIndexSync_DeletedArtistLeavesSongs(t *testing.T) {
+	_, songService, artistService, dispatcher, adapter := setupDispatcherTest(t, 0)
+
+	oasis, _ := artistService.CreateArtist("Oasis", "", "")
+	noel, _ := artistService.CreateArtist("Noel Gallagher", "", "")
+	song, _, _ := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today", ArtistIds: []uint{oasis.ID, noel.ID}}, 1)
+	dispatcher.DispatchDue()
+
+	assert.NoError(t, artistService.DeleteArtist(oasis.ID))
+	_, err := dispatcher.DispatchDue()
+	assert.NoError(t, err)
+
+	assert.Empty(t, searchHits(t, adapter, "oasis", opensearch.SearchFilters{}), "expected the artist and its name on songs to be gone")
+	assert.Equal(t,
+		[]string{fmt.Sprintf("artist_%d", noel.ID), fmt.Sprintf("song_%d", song.ID)},
+		searchHits(t, adapter, "gallagher", opensearch.SearchFilters{}))
+}
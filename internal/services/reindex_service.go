@@ -0,0 +1,153 @@
+package services
+
+import (
+	"chords_app/internal/adapters/opensearch"
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReindexService interface {
+	Reindex(batchSize int, deleteOld bool) (string, error)
+}
+
+type reindexService struct {
+	songRepo     repositories.SongRepository
+	artistRepo   repositories.ArtistRepository
+	outboxRepo   repositories.OutboxRepository
+	indexManager opensearch.IndexManager
+	db           *gorm.DB
+}
+
+func NewReindexService(
+	songRepo repositories.SongRepository,
+	artistRepo repositories.ArtistRepository,
+	outboxRepo repositories.OutboxRepository,
+	indexManager opensearch.IndexManager,
+	db *gorm.DB,
+) ReindexService {
+	return &reindexService{songRepo, artistRepo, outboxRepo, indexManager, db}
+}
+
+// Reindex rebuilds the search index from the database into a fresh
+// versioned index and swaps the alias over to it. Rows changed while the
+// rebuild was running are queued in the outbox so the dispatcher brings
+// the new index up to date. It returns the name of the new index.
+func (s *reindexService) Reindex(batchSize int, deleteOld bool) (string, error) {
+	startedAt := time.Now()
+	indexName := s.indexManager.NewVersionedIndexName()
+
+	if err := s.indexManager.CreateIndex(indexName); err != nil {
+		return "", err
+	}
+	slog.Info("Created index", slog.String("index", indexName))
+
+	var artistCount int
+	err := s.artistRepo.FindArtistsInBatches(s.db, batchSize, func(artists *[]models.Artist) error {
+		docs := make([]opensearch.Document, 0, len(*artists))
+		for _, artist := range *artists {
+			docs = append(docs, opensearch.ArtistDocument(&artist))
+		}
+		artistCount += len(docs)
+		return s.indexManager.BulkIndex(indexName, docs)
+	})
+	if err != nil {
+		return "", err
+	}
+	slog.Info("Indexed artists", slog.Int("count", artistCount))
+
+	var songCount int
+	err = s.songRepo.FindSongsInBatches(s.db, batchSize, func(songs *[]models.Song) error {
+		docs := make([]opensearch.Document, 0, len(*songs))
+		for _, song := range *songs {
+			artists := make([]models.Artist, 0, len(song.Artists))
+			for _, songArtist := range song.Artists {
+				// Deleted artists are not preloaded and stay zero valued.
+				if songArtist.Artist.ID != 0 {
+					artists = append(artists, songArtist.Artist)
+				}
+			}
+			docs = append(docs, opensearch.SongDocument(&song, artists))
+		}
+		songCount += len(docs)
+		return s.indexManager.BulkIndex(indexName, docs)
+	})
+	if err != nil {
+		return "", err
+	}
+	slog.Info("Indexed songs", slog.Int("count", songCount))
+
+	if err := s.indexManager.RefreshIndex(indexName); err != nil {
+		return "", err
+	}
+
+	previous, err := s.indexManager.SwapAlias(indexName)
+	if err != nil {
+		return "", err
+	}
+	slog.Info("Swapped alias", slog.String("index", indexName), slog.Any("previous", previous))
+
+	if err := s.enqueueChangedSince(startedAt); err != nil {
+		return indexName, err
+	}
+
+	if deleteOld {
+		if err := s.indexManager.DeleteIndices(previous); err != nil {
+			return indexName, err
+		}
+		slog.Info("Deleted previous indices", slog.Any("indices", previous))
+	}
+
+	return indexName, nil
+}
+
+// enqueueChangedSince queues the rows changed since the time, with the
+// songs of every changed artist since songs carry denormalized artist
+// names.
+func (s *reindexService) enqueueChangedSince(since time.Time) error {
+	songIds, err := s.songRepo.GetChangedSongIds(s.db, since)
+	if err != nil {
+		return err
+	}
+	artistIds, err := s.artistRepo.GetChangedArtistIds(s.db, since)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[uint]bool, len(songIds))
+	for _, id := range songIds {
+		seen[id] = true
+	}
+	for _, artistId := range artistIds {
+		songs, err := s.artistRepo.GetArtistSongs(s.db, artistId)
+		if err != nil {
+			return err
+		}
+		if songs == nil {
+			continue
+		}
+		for _, song := range *songs {
+			if !seen[song.ID] {
+				seen[song.ID] = true
+				songIds = append(songIds, song.ID)
+			}
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range songIds {
+			if err := s.outboxRepo.Enqueue(tx, repositories.OutboxIndex, opensearch.SongType, id); err != nil {
+				return err
+			}
+		}
+		for _, id := range artistIds {
+			if err := s.outboxRepo.Enqueue(tx, repositories.OutboxIndex, opensearch.ArtistType, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
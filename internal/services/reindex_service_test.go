@@ -0,0 +1,113 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"chords_app/internal/adapters/opensearch"
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingIndexManager struct {
+	created []string
+	bulks   map[string][]opensearch.Document
+	alias   []string
+	deleted []string
+}
+
+func (m *recordingIndexManager) NewVersionedIndexName() string { return "songs_v2" }
+
+func (m *recordingIndexManager) CreateIndex(name string) error {
+	m.created = append(m.created, name)
+	return nil
+}
+
+func (m *recordingIndexManager) BulkIndex(indexName string, docs []opensearch.Document) error {
+	m.bulks[indexName] = append(m.bulks[indexName], docs...)
+	return nil
+}
+
+func (m *recordingIndexManager) RefreshIndex(name string) error { return nil }
+
+func (m *recordingIndexManager) SwapAlias(newIndex string) ([]string, error) {
+	previous := m.alias
+	m.alias = []string{newIndex}
+	return previous, nil
+}
+
+func (m *recordingIndexManager) DeleteIndices(names []string) error {
+	m.deleted = append(m.deleted, names...)
+	return nil
+}
+
+func TestReindex_RebuildsIndexAndSwapsAlias(t *testing.T) {
+	db, songService, artistService, dispatcher, _ := setupDispatcherTest(t, 0)
+
+	oasis, _ := artistService.CreateArtist("Oasis", "", "")
+	blur, _ := artistService.CreateArtist("Blur", "", "")
+	songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today", ArtistIds: []uint{oasis.ID}}, 1)
+	songService.UploadSong(SongInput{Title: "Song 2", Content: "[A]Woo hoo", ArtistIds: []uint{blur.ID}}, 1)
+	songService.UploadSong(SongInput{Title: "Charity", Content: "[C]Hm", ArtistIds: []uint{oasis.ID, blur.ID}}, 1)
+	dispatcher.DispatchDue()
+	artistService.DeleteArtist(blur.ID)
+	dispatcher.DispatchDue()
+
+	manager := &recordingIndexManager{bulks: map[string][]opensearch.Document{}, alias: []string{"songs_v1"}}
+	service := NewReindexService(
+		repositories.NewGormSongRepository(),
+		repositories.NewGormArtistRepository(),
+		repositories.NewGormOutboxRepository(),
+		manager,
+		db,
+	)
+
+	indexName, err := service.Reindex(2, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "songs_v2", indexName)
+	assert.Equal(t, []string{"songs_v2"}, manager.created)
+	assert.Equal(t, []string{"songs_v2"}, manager.alias)
+	assert.Equal(t, []string{"songs_v1"}, manager.deleted)
+
+	docs := map[string]opensearch.Document{}
+	for _, doc := range manager.bulks["songs_v2"] {
+		docs[doc.ID] = doc
+	}
+	assert.Len(t, docs, 4, "expected one artist and three songs")
+	assert.NotContains(t, docs, "artist_2", "expected deleted artist to be skipped")
+	assert.Equal(t, []string{"Oasis"}, docs["song_3"].Body["artists"])
+
+	var pending int64
+	db.Model(&models.SearchOutbox{}).Count(&pending)
+	assert.Zero(t, pending, "expected no rows to change during reindex")
+}
+
+func TestReindex_QueuesSongsOfArtistsRenamedDuringRebuild(t *testing.T) {
+	db, songService, artistService, dispatcher, _ := setupDispatcherTest(t, 0)
+
+	oasis, _ := artistService.CreateArtist("Oasis", "", "")
+	song, _, _ := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today", ArtistIds: []uint{oasis.ID}}, 1)
+	dispatcher.DispatchDue()
+
+	startedAt := time.Now()
+	db.Model(&models.Artist{}).Where("id = ?", oasis.ID).Update("name", "Noel Gallagher")
+
+	service := NewReindexService(
+		repositories.NewGormSongRepository(),
+		repositories.NewGormArtistRepository(),
+		repositories.NewGormOutboxRepository(),
+		&recordingIndexManager{bulks: map[string][]opensearch.Document{}},
+		db,
+	).(*reindexService)
+	assert.NoError(t, service.enqueueChangedSince(startedAt))
+
+	var entries []models.SearchOutbox
+	db.Order("obj_type").Find(&entries)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, opensearch.ArtistType, entries[0].ObjType)
+		assert.Equal(t, opensearch.SongType, entries[1].ObjType, "expected the song to pick up the new artist name")
+		assert.Equal(t, song.ID, entries[1].ObjID)
+	}
+}
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"chords_app/internal/adapters/opensearch"
+	"chords_app/internal/chords"
+	"chords_app/internal/repositories"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type SearchResultsDTO struct {
+	Songs   []SongHitDTO
+	Artists []ArtistHitDTO
+	Facets  map[string][]FacetBucketDTO
+}
+
+// SearchFilters narrows search results to songs. Key accepts any spelling
+// chords.ParseKey understands and Tuning any tuning name.
+type SearchFilters struct {
+	ArtistIds  []uint
+	Key        string
+	Mode       string
+	Instrument string
+	Tuning     string
+	Difficulty string
+	Tag        string
+	UploadedBy uint
+}
+
+// FacetBucketDTO is a filter value with the number of matching songs.
+// Label is the artist name for the artist facet and empty otherwise.
+type FacetBucketDTO struct {
+	Value string
+	Label string
+	Count uint
+}
+
+// SongHitDTO is a song search result with snippets of the title,
+// description and lyrics showing why it matched.
+type SongHitDTO struct {
+	SongDTO
+	Highlights map[string][]string
+}
+
+// ArtistHitDTO is an artist search result with its name highlighted.
+type ArtistHitDTO struct {
+	ArtistDTO
+	Highlights map[string][]string
+}
+
+type SuggestionDTO struct {
+	ID   uint
+	Type string
+	Text string
+}
+
+// Errors of Search and SearchProgression for invalid requests.
+var (
+	ErrQueryRequired       = errors.New("`q` parameter or a filter is required")
+	ErrInvalidDifficulty   = errors.New("invalid difficulty, should be one of [easy, medium, hard]")
+	ErrProgressionTooShort = errors.New("progression should contain at least 2 different chords")
+)
+
+type SearchService interface {
+	Search(query string, filters SearchFilters, limit, offset uint) (*SearchResultsDTO, error)
+	Suggest(query string, limit uint) (*[]SuggestionDTO, error)
+	SearchProgression(chordSymbols []string, limit, offset uint) (*[]SongDTO, error)
+}
+
+const (
+	// Songs are indexed relative to their detected key, so the reading of
+	// the query in its own detected key is the most likely match. The
+	// other keys cover songs whose key was detected differently.
+	queryKeyBoost        = 2
+	transposedBoost      = 1
+	minProgressionChords = 2
+)
+
+type searchService struct {
+	osAdapter  opensearch.SearchAdapter
+	songRepo   repositories.SongRepository
+	artistRepo repositories.ArtistRepository
+	db         *gorm.DB
+}
+
+func NewSearchService(
+	osAdapter opensearch.SearchAdapter,
+	songRepo repositories.SongRepository,
+	artistRepo repositories.ArtistRepository,
+	db *gorm.DB,
+) SearchService {
+	return &searchService{osAdapter, songRepo, artistRepo, db}
+}
+
+func (s *searchService) Search(query string, filters SearchFilters, limit, offset uint) (*SearchResultsDTO, error) {
+	indexFilters, err := toIndexFilters(filters)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(query) == "" && indexFilters.IsEmpty() {
+		return nil, ErrQueryRequired
+	}
+
+	response, err := s.osAdapter.Search(query, indexFilters, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	results := SearchResultsDTO{
+		Songs:   make([]SongHitDTO, 0),
+		Artists: make([]ArtistHitDTO, 0),
+		Facets:  s.facetsToDTO(response.Facets),
+	}
+
+	for _, hit := range response.Hits {
+		switch hit.ObjType {
+		case opensearch.SongType:
+			song, ok := s.songHit(hit)
+			if !ok {
+				continue
+			}
+			results.Songs = append(results.Songs, SongHitDTO{*song, hit.Highlights})
+		case opensearch.ArtistType:
+			artist, err := s.artistRepo.GetArtistById(s.db, hit.ObjId)
+			if err != nil || artist == nil {
+				slog.Warn("search hit does not match an artist", slog.Uint64("id", uint64(hit.ObjId)))
+				continue
+			}
+			results.Artists = append(results.Artists, ArtistHitDTO{ArtistDTO{artist.ID, artist.Name}, hit.Highlights})
+		}
+	}
+
+	return &results, nil
+}
+
+func toIndexFilters(filters SearchFilters) (opensearch.SearchFilters, error) {
+	indexFilters := opensearch.SearchFilters{
+		ArtistIds:  filters.ArtistIds,
+		Tag:        strings.ToLower(strings.TrimSpace(filters.Tag)),
+		UploadedBy: filters.UploadedBy,
+	}
+
+	key, mode, err := normalizeKeyFilter(filters.Key, filters.Mode)
+	if err != nil {
+		return opensearch.SearchFilters{}, err
+	}
+	indexFilters.Key, indexFilters.Mode = key, mode
+
+	instrument, tuning, err := normalizeTuningFilter(filters.Instrument, filters.Tuning)
+	if err != nil {
+		return opensearch.SearchFilters{}, err
+	}
+	indexFilters.Instrument, indexFilters.Tuning = instrument, tuning
+
+	if filters.Difficulty != "" {
+		difficulty, ok := chords.ParseDifficulty(filters.Difficulty)
+		if !ok {
+			return opensearch.SearchFilters{}, ErrInvalidDifficulty
+		}
+		indexFilters.Difficulty = string(difficulty)
+	}
+
+	return indexFilters, nil
+}
+
+// facetsToDTO labels artist buckets with artist names, dropping buckets of
+// artists that no longer exist.
+func (s *searchService) facetsToDTO(facets map[string][]opensearch.FacetBucket) map[string][]FacetBucketDTO {
+	facetDTOs := make(map[string][]FacetBucketDTO, len(facets))
+	for facet, buckets := range facets {
+		bucketDTOs := make([]FacetBucketDTO, 0, len(buckets))
+		for _, bucket := range buckets {
+			bucketDTO := FacetBucketDTO{Value: bucket.Value, Count: bucket.Count}
+			if facet == opensearch.FacetArtist {
+				artistId, err := strconv.ParseUint(bucket.Value, 10, 32)
+				if err != nil {
+					continue
+				}
+				artist, err := s.artistRepo.GetArtistById(s.db, uint(artistId))
+				if err != nil || artist == nil {
+					continue
+				}
+				bucketDTO.Label = artist.Name
+			}
+			bucketDTOs = append(bucketDTOs, bucketDTO)
+		}
+		facetDTOs[facet] = bucketDTOs
+	}
+	return facetDTOs
+}
+
+// Suggest returns the indexed titles and names directly, without loading
+// rows from the database, to keep autocomplete latency low.
+func (s *searchService) Suggest(query string, limit uint) (*[]SuggestionDTO, error) {
+	suggestions, err := s.osAdapter.Suggest(query, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestionDTOs := make([]SuggestionDTO, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		suggestionDTOs = append(suggestionDTOs, SuggestionDTO{
+			ID:   suggestion.ObjId,
+			Type: suggestion.ObjType,
+			Text: suggestion.Text,
+		})
+	}
+	return &suggestionDTOs, nil
+}
+
+// SearchProgression finds songs containing the chord progression in any
+// key. Songs are indexed as scale degrees, so the query is expanded into
+// the degrees it would have in each of the 12 major keys.
+func (s *searchService) SearchProgression(chordSymbols []string, limit, offset uint) (*[]SongDTO, error) {
+	sequence := make([]chords.Chord, 0, len(chordSymbols))
+	for _, symbol := range chordSymbols {
+		chord, err := chords.ParseChord(symbol)
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, chord)
+	}
+
+	queryKey, ok := chords.DetectKey(sequence)
+	if !ok || len(chords.ProgressionTokens(sequence, queryKey)) < minProgressionChords {
+		return nil, ErrProgressionTooShort
+	}
+
+	phrases := make([]opensearch.WeightedPhrase, 0, 12)
+	for tonic := 0; tonic < 12; tonic++ {
+		key := chords.Key{Tonic: tonic}
+		boost := float32(transposedBoost)
+		if key == queryKey.RelativeMajor() {
+			boost = queryKeyBoost
+		}
+		phrases = append(phrases, opensearch.WeightedPhrase{
+			Phrase: strings.Join(chords.ProgressionTokens(sequence, key), " "),
+			Boost:  boost,
+		})
+	}
+
+	hits, err := s.osAdapter.SearchProgression(phrases, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	songs := make([]SongDTO, 0, len(hits))
+	for _, hit := range hits {
+		if song, ok := s.songHit(hit); ok {
+			songs = append(songs, *song)
+		}
+	}
+	return &songs, nil
+}
+
+func (s *searchService) songHit(hit opensearch.QueryResult) (*SongDTO, bool) {
+	song, err := s.songRepo.GetSongWithArtists(s.db, hit.ObjId)
+	if err != nil {
+		// The index can lag behind the database, skip documents
+		// whose rows no longer exist.
+		slog.Warn("search hit does not match a song", slog.Uint64("id", uint64(hit.ObjId)), slog.String("error", err.Error()))
+		return nil, false
+	}
+
+	songDTO := newSongDTO(song, loadSongArtists(s.artistRepo, s.db, song.Artists))
+	return &songDTO, true
+}
@@ -1,23 +1,52 @@
 package services
 
 import (
+	"chords_app/internal/adapters/opensearch"
+	"chords_app/internal/chords"
 	"chords_app/internal/models"
 	"chords_app/internal/repositories"
 	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
 )
 
 type SongService interface {
-	GetMostPopularSongs(period string, limit, offset uint) (*[]SongDTOWithViews, error)
-	UploadSong(title, description, content string, uploadedBy uint, artistIds []uint) (*models.Song, *[]models.SongArtist, error)
-	UpdateSong(songId uint, title, description, content string, artistIds []uint) (*models.Song, *[]models.SongArtist, error)
+	GetMostPopularSongs(period, key, mode, instrument, tuning string, limit, offset uint) (*[]SongDTOWithViews, error)
+	UploadSong(input SongInput, uploadedBy uint) (*models.Song, *[]models.SongArtist, error)
+	UpdateSong(songId uint, input SongInput) (*models.Song, *[]models.SongArtist, error)
 	GetSongWithArtists(songId uint) (*models.Song, error)
+	RenderContent(song *models.Song, options RenderOptions) (*RenderedContentDTO, error)
+	GetSongChords(songId uint, instrument string, options RenderOptions) (*SongChordsDTO, error)
+	PreviewImport(content string) (*ImportPreviewDTO, error)
 	DeleteSong(songId uint) error
+	BackfillSongKeys() (int, error)
+}
+
+// SongInput is a song as sent to UploadSong and UpdateSong. Capo is nil
+// when it is not given.
+type SongInput struct {
+	Title       string
+	Description string
+	Content     string
+	Capo        *uint
+	Instrument  string
+	Tuning      string
+	ArtistIds   []uint
+	Tags        []string
 }
 
 type SongDTO struct {
-	ID      uint
-	Title   string
-	Artists []ArtistDTO
+	ID         uint
+	Title      string
+	Key        string
+	Mode       string
+	Instrument string
+	Tuning     string
+	Artists    []ArtistDTO
 }
 
 type SongDTOWithViews struct {
@@ -25,16 +54,93 @@ type SongDTOWithViews struct {
 	Views uint
 }
 
+// RenderOptions change how song content is presented without changing the
+// stored song.
+type RenderOptions struct {
+	// Transpose shifts every chord by this many semitones.
+	Transpose int
+	// Capo is the fret to play the song with a capo on. Nil keeps the
+	// song's own capo.
+	Capo *uint
+	// Notation writes chords relative to the key instead of as symbols.
+	// Empty means chords.LetterNotation.
+	Notation chords.Notation
+	// Simplify reduces chords to basic triads and, when Capo is nil,
+	// picks the capo that needs the fewest barre chords.
+	Simplify bool
+}
+
+// RenderedContentDTO is song content as chord shapes to play with a capo
+// on Capo, and with the chords at the pitch they sound. Difficulty is only
+// set for simplified content.
+type RenderedContentDTO struct {
+	Content        string
+	ConcertContent string
+	Capo           uint
+	Difficulty     *DifficultyChangeDTO
+}
+
+// DifficultyChangeDTO compares the shapes played without and with
+// simplification.
+type DifficultyChangeDTO struct {
+	Before DifficultyDTO
+	After  DifficultyDTO
+}
+
+type DifficultyDTO struct {
+	Level chords.Difficulty
+	Score float64
+}
+
+// SongChordsDTO lists the distinct chord shapes of a song, as played with
+// a capo on Capo, with their diagrams for the instrument in the tuning
+// named TuningName. Tuning spells its open strings.
+type SongChordsDTO struct {
+	Instrument chords.Instrument
+	TuningName string
+	Tuning     []string
+	Capo       uint
+	Chords     []SongChordDTO
+}
+
+type SongChordDTO struct {
+	Symbol   string
+	Voicings []chords.Voicing
+}
+
+// ImportPreviewDTO is content as UploadSong would store it. Converted is
+// true when it was pasted as chords over lyrics and turned into ChordPro.
+type ImportPreviewDTO struct {
+	Content   string
+	Converted bool
+}
+
+// voicingsPerChord is how many diagrams are returned for every chord.
+const voicingsPerChord = 3
+
+// backfillBatchSize is how many songs BackfillSongKeys reads at once.
+const backfillBatchSize = 200
+
 type songService struct {
 	repo       repositories.SongRepository
 	artistRepo repositories.ArtistRepository
+	outboxRepo repositories.OutboxRepository
+	db         *gorm.DB
 }
 
-func NewSongService(repo repositories.SongRepository, artistRepo repositories.ArtistRepository) SongService {
-	return &songService{repo, artistRepo}
+func NewSongService(
+	repo repositories.SongRepository,
+	artistRepo repositories.ArtistRepository,
+	outboxRepo repositories.OutboxRepository,
+	db *gorm.DB,
+) SongService {
+	return &songService{repo, artistRepo, outboxRepo, db}
 }
 
-func (s *songService) GetMostPopularSongs(period string, limit, offset uint) (*[]SongDTOWithViews, error) {
+// GetMostPopularSongs lists songs by views. Key, mode, instrument and
+// tuning are optional filters; key accepts any spelling chords.ParseKey
+// understands and tuning any tuning name.
+func (s *songService) GetMostPopularSongs(period, key, mode, instrument, tuning string, limit, offset uint) (*[]SongDTOWithViews, error) {
 	var days uint
 
 	switch period {
@@ -52,7 +158,17 @@ func (s *songService) GetMostPopularSongs(period string, limit, offset uint) (*[
 		return nil, errors.New("invalid period, should by one of [day, week, month, year, allTime]")
 	}
 
-	songs, err := s.repo.GetPopularSongsForPeriod(days, limit, offset)
+	key, mode, err := normalizeKeyFilter(key, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	instrument, tuning, err = normalizeTuningFilter(instrument, tuning)
+	if err != nil {
+		return nil, err
+	}
+
+	songs, err := s.repo.GetPopularSongsForPeriod(s.db, days, key, mode, instrument, tuning, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -62,23 +178,8 @@ func (s *songService) GetMostPopularSongs(period string, limit, offset uint) (*[
 func (s *songService) songstoSongDTO(songs *[]repositories.SongWithViews) (*[]SongDTOWithViews, error) {
 	songDTOs := make([]SongDTOWithViews, 0, len(*songs))
 	for _, song := range *songs {
-		artists := make([]ArtistDTO, 0, len(song.Artists))
-
-		for _, songArtist := range song.Artists {
-			artist, err := s.artistRepo.GetArtistById(songArtist.ArtistID)
-			if err != nil || artist == nil {
-				continue
-			}
-			artists = append(artists, ArtistDTO{artist.ID, artist.Name})
-		}
-
-		songDTO := SongDTO{
-			ID:      song.ID,
-			Title:   song.Title,
-			Artists: artists,
-		}
 		songDTOWithViews := SongDTOWithViews{
-			songDTO,
+			newSongDTO(&song.Song, loadSongArtists(s.artistRepo, s.db, song.Artists)),
 			song.ViewCount,
 		}
 		songDTOs = append(songDTOs, songDTOWithViews)
@@ -87,99 +188,571 @@ func (s *songService) songstoSongDTO(songs *[]repositories.SongWithViews) (*[]So
 	return &songDTOs, nil
 }
 
-func (s *songService) UploadSong(title, description, content string, uploadedBy uint, artistIds []uint) (*models.Song, *[]models.SongArtist, error) {
+// newSongDTO lists a song with the given artists, which are loaded apart
+// because songs only reference them.
+func newSongDTO(song *models.Song, artists []models.Artist) SongDTO {
+	artistDTOs := make([]ArtistDTO, 0, len(artists))
+	for _, artist := range artists {
+		artistDTOs = append(artistDTOs, ArtistDTO{artist.ID, artist.Name})
+	}
+	return SongDTO{
+		ID:         song.ID,
+		Title:      song.Title,
+		Key:        song.Key,
+		Mode:       song.Mode,
+		Instrument: song.Instrument,
+		Tuning:     song.Tuning,
+		Artists:    artistDTOs,
+	}
+}
+
+// UploadSong creates a song. Chords-over-lyrics content is converted to
+// ChordPro first; content must then be valid ChordPro, otherwise the
+// returned error is chords.ParseErrors, which also covers tab blocks that
+// do not fit the instrument and tuning. Without capo the song takes the
+// capo from a {capo} directive in the content, and without instrument and
+// tuning the ones of {instrument} and {tuning} directives, guitar in
+// standard tuning otherwise.
+func (s *songService) UploadSong(input SongInput, uploadedBy uint) (*models.Song, *[]models.SongArtist, error) {
+	if err := ValidateCapo(input.Capo); err != nil {
+		return nil, nil, err
+	}
+	content, _ := importContent(input.Content)
+	metadata, err := parseSongMetadata(content, input.Instrument, input.Tuning, input.Capo, chords.StandardGuitar)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	song := models.Song{
-		Title:       title,
-		Description: description,
+		Title:       input.Title,
+		Description: input.Description,
 		Content:     content,
 		UploadedBy:  uploadedBy,
 	}
+	setSongTuning(&song, metadata.tuning)
+	if metadata.capo != nil {
+		song.Capo = *metadata.capo
+	}
+	detectSongKey(&song)
+
+	tx := s.db.Begin()
 
-	if err := s.repo.CreateSong(&song); err != nil {
+	if err := s.repo.CreateSong(tx, &song); err != nil {
+		tx.Rollback()
 		return nil, nil, err
 	}
 
-	songArtists := make([]models.SongArtist, 0, len(artistIds))
+	songArtists, err := s.attachAuthors(tx, song.ID, input.ArtistIds)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
 
-	for i, artistId := range artistIds {
-		artist, err := s.artistRepo.GetArtistById(artistId)
-		if err != nil || artist == nil {
-			return nil, nil, errors.New("artist not found")
-		}
+	if err := s.repo.SetSongTags(tx, &song, normalizeTags(input.Tags)); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
 
-		songArtist := models.SongArtist{
-			ArtistID:   artistId,
-			SongID:     song.ID,
-			TitleOrder: i,
-		}
-		if err := s.repo.AttachAuthor(&songArtist); err != nil {
-			return nil, nil, err
-		}
-		songArtists = append(songArtists, songArtist)
+	if err := s.outboxRepo.Enqueue(tx, repositories.OutboxIndex, opensearch.SongType, song.ID); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, err
 	}
 
 	return &song, &songArtists, nil
 }
 
 func (s *songService) GetSongWithArtists(songId uint) (*models.Song, error) {
-	if err := s.repo.AddSongRequest(songId); err != nil {
+	if err := s.repo.AddSongRequest(s.db, songId); err != nil {
 		return nil, err
 	}
-	return s.repo.GetSongWithArtists(songId)
+	return s.repo.GetSongWithArtists(s.db, songId)
 }
 
-func (s *songService) UpdateSong(songId uint, title, description, content string, artistIds []uint) (*models.Song, *[]models.SongArtist, error) {
-	song, err := s.repo.GetSongWithArtists(songId)
+// UpdateSong updates the non-empty fields of the input. Tags are replaced
+// when they are not nil, so an empty slice clears them. New content is converted and
+// validated like in UploadSong, and without capo, instrument and tuning
+// the song takes them from its {capo}, {instrument} and {tuning}
+// directives. A new instrument without a tuning is played in its
+// standard tuning, a new tuning without an instrument on the song's
+// instrument. The content is validated again when only the tuning
+// changes.
+func (s *songService) UpdateSong(songId uint, input SongInput) (*models.Song, *[]models.SongArtist, error) {
+	if err := ValidateCapo(input.Capo); err != nil {
+		return nil, nil, err
+	}
+
+	song, err := s.repo.GetSongWithArtists(s.db, songId)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if title != "" {
-		song.Title = title
+	newTuning := songTuning(song)
+	content, _ := importContent(input.Content)
+	capo := input.Capo
+	if content != "" || input.Instrument != "" || input.Tuning != "" {
+		effective := content
+		if effective == "" {
+			effective = song.Content
+		}
+		metadata, err := parseSongMetadata(effective, input.Instrument, input.Tuning, capo, newTuning)
+		if err != nil {
+			return nil, nil, err
+		}
+		newTuning = metadata.tuning
+		// Only new content says what the capo is.
+		if content != "" {
+			capo = metadata.capo
+		}
 	}
-	if description != "" {
-		song.Description = description
+
+	if input.Title != "" {
+		song.Title = input.Title
+	}
+	if input.Description != "" {
+		song.Description = input.Description
 	}
 	if content != "" {
 		song.Content = content
 	}
+	if capo != nil {
+		song.Capo = *capo
+	}
+	setSongTuning(song, newTuning)
+	detectSongKey(song)
 
-	if err := s.repo.UpdateSong(song); err != nil {
+	tx := s.db.Begin()
+
+	if err := s.repo.UpdateSong(tx, song); err != nil {
+		tx.Rollback()
 		return nil, nil, err
 	}
 
-	if len(artistIds) > 0 {
+	if len(input.ArtistIds) > 0 {
 		for _, songArtist := range song.Artists {
-			s.repo.DeattachAuthor(&songArtist)
+			s.repo.DeattachAuthor(tx, &songArtist)
 		}
 
-		songArtists := make([]models.SongArtist, 0, len(artistIds))
+		songArtists, err := s.attachAuthors(tx, song.ID, input.ArtistIds)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		song.Artists = songArtists
+	}
 
-		for i, artistId := range artistIds {
-			artist, err := s.artistRepo.GetArtistById(artistId)
-			if err != nil || artist == nil {
-				return nil, nil, errors.New("artist not found")
-			}
+	if input.Tags != nil {
+		if err := s.repo.SetSongTags(tx, song, normalizeTags(input.Tags)); err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+	}
 
-			songArtist := models.SongArtist{
-				ArtistID:   artistId,
-				SongID:     song.ID,
-				TitleOrder: i,
+	if err := s.outboxRepo.Enqueue(tx, repositories.OutboxIndex, opensearch.SongType, song.ID); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, err
+	}
+
+	return song, &song.Artists, nil
+}
+
+// BackfillSongKeys detects the key of songs stored before keys were, so
+// the key and mode filters find them like search does. Songs whose key
+// cannot be detected are left alone. It returns the number of songs
+// updated.
+func (s *songService) BackfillSongKeys() (int, error) {
+	updated := 0
+	err := s.repo.FindSongsInBatches(s.db.Where("songs.key = ?", ""), backfillBatchSize, func(songs *[]models.Song) error {
+		for i := range *songs {
+			song := &(*songs)[i]
+			detectSongKey(song)
+			if song.Key == "" {
+				continue
 			}
-			if err := s.repo.AttachAuthor(&songArtist); err != nil {
-				return nil, nil, err
+			if err := s.repo.SetSongKey(s.db, song); err != nil {
+				return err
 			}
-			songArtists = append(songArtists, songArtist)
+			updated++
 		}
-		song.Artists = songArtists
-	}
-	return song, &song.Artists, nil
+		return nil
+	})
+	return updated, err
 }
 
 func (s *songService) DeleteSong(songId uint) error {
-	song, err := s.repo.GetSongById(songId)
+	song, err := s.repo.GetSongById(s.db, songId)
 	if err != nil || song == nil {
 		return errors.New("song not found")
 	}
-	return s.repo.DeleteSong(song)
+
+	tx := s.db.Begin()
+	if err := s.repo.DeleteSong(tx, song); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := s.outboxRepo.Enqueue(tx, repositories.OutboxDelete, opensearch.SongType, song.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (s *songService) attachAuthors(tx *gorm.DB, songId uint, artistIds []uint) ([]models.SongArtist, error) {
+	songArtists := make([]models.SongArtist, 0, len(artistIds))
+
+	for i, artistId := range artistIds {
+		artist, err := s.artistRepo.GetArtistById(tx, artistId)
+		if err != nil || artist == nil {
+			return nil, errors.New("artist not found")
+		}
+
+		songArtist := models.SongArtist{
+			ArtistID:   artistId,
+			SongID:     songId,
+			TitleOrder: i,
+		}
+		if err := s.repo.AttachAuthor(tx, &songArtist); err != nil {
+			return nil, err
+		}
+		songArtists = append(songArtists, songArtist)
+	}
+
+	return songArtists, nil
+}
+
+// RenderContent applies the options to the song's ChordPro content.
+// Content is returned as is when there is nothing to apply. With a
+// notation other than chords, the returned content is for display only:
+// its chords no longer parse.
+func (s *songService) RenderContent(song *models.Song, options RenderOptions) (*RenderedContentDTO, error) {
+	capo := song.Capo
+	if options.Capo != nil {
+		capo = *options.Capo
+	}
+	if err := ValidateCapo(&capo); err != nil {
+		return nil, err
+	}
+
+	plain := options.Notation == "" || options.Notation == chords.LetterNotation
+	if plain && !options.Simplify && options.Transpose == 0 && capo == 0 && song.Capo == 0 {
+		return &RenderedContentDTO{song.Content, song.Content, 0, nil}, nil
+	}
+
+	sheet, err := chords.ParseChordPro(song.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	concert := sheet
+	concert.ApplyCapo(song.Capo, 0)
+	concert.Transpose(options.Transpose)
+
+	var before *chords.Sheet
+	if options.Simplify {
+		before = concert.Clone()
+		before.ApplyCapo(0, capo)
+
+		concert.Simplify()
+		if options.Capo == nil {
+			capo = chords.BestCapo(concert.Chords())
+		}
+	}
+
+	shapes := concert.Clone()
+	shapes.ApplyCapo(0, capo)
+
+	rendered := &RenderedContentDTO{Capo: capo}
+	if before != nil {
+		rendered.Difficulty = &DifficultyChangeDTO{
+			Before: difficultyOf(before.Chords()),
+			After:  difficultyOf(shapes.Chords()),
+		}
+	}
+
+	concert.ApplyNotation(options.Notation)
+	shapes.ApplyNotation(options.Notation)
+	rendered.Content = shapes.String()
+	rendered.ConcertContent = concert.String()
+	return rendered, nil
+}
+
+// difficultyOf rates chord shapes, with the score rounded to hundredths.
+func difficultyOf(sequence []chords.Chord) DifficultyDTO {
+	level, _ := chords.EstimateDifficulty(sequence)
+	score, _ := chords.DifficultyScore(sequence)
+	return DifficultyDTO{level, math.Round(score*100) / 100}
+}
+
+// GetSongChords returns the chords of the song in order of first
+// appearance with up to three voicings each. Options are applied like in
+// RenderContent, so the chords match the rendered content. Voicings are
+// for the song's instrument and tuning, or for the standard tuning of
+// another instrument when one is given.
+func (s *songService) GetSongChords(songId uint, instrument string, options RenderOptions) (*SongChordsDTO, error) {
+	var requested chords.Instrument
+	if instrument != "" {
+		parsed, err := chords.ParseInstrument(instrument)
+		if err != nil {
+			return nil, ErrInvalidInstrument
+		}
+		requested = parsed
+	}
+
+	song, err := s.repo.GetSongWithArtists(s.db, songId)
+	if err != nil {
+		return nil, err
+	}
+
+	tuning := songTuning(song)
+	if requested != "" && requested != tuning.Instrument {
+		tuning, _ = chords.StandardTuning(requested)
+	}
+
+	options.Notation = chords.LetterNotation
+	rendered, err := s.RenderContent(song, options)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := chords.ParseChordPro(rendered.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	songChords := make([]SongChordDTO, 0)
+	seen := make(map[string]bool)
+	for _, chord := range sheet.Chords() {
+		symbol := chord.String()
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		songChords = append(songChords, SongChordDTO{
+			Symbol:   symbol,
+			Voicings: chords.Voicings(chord, tuning, voicingsPerChord),
+		})
+	}
+
+	return &SongChordsDTO{
+		Instrument: tuning.Instrument,
+		TuningName: tuning.Name,
+		Tuning:     tuning.StringNames(),
+		Capo:       rendered.Capo,
+		Chords:     songChords,
+	}, nil
+}
+
+// PreviewImport shows how UploadSong would store the content without
+// storing it. The error is chords.ParseErrors when the result is not
+// valid ChordPro or its tab blocks do not fit the tuning of its directives.
+func (s *songService) PreviewImport(content string) (*ImportPreviewDTO, error) {
+	content, converted := importContent(content)
+	if _, err := parseSongMetadata(content, "", "", nil, chords.StandardGuitar); err != nil {
+		return nil, err
+	}
+	return &ImportPreviewDTO{content, converted}, nil
+}
+
+// importContent converts chords-over-lyrics content to ChordPro and
+// leaves any other content as is.
+func importContent(content string) (string, bool) {
+	if !chords.IsChordsOverLyrics(content) {
+		return content, false
+	}
+	return chords.ImportChordsOverLyrics(content), true
+}
+
+// songMetadata is how a song is played according to its content and the
+// fields it was sent with.
+type songMetadata struct {
+	sheet  *chords.Sheet
+	tuning chords.Tuning
+	capo   *uint
+}
+
+// parseSongMetadata parses ChordPro content and reads how the song is
+// played. The instrument and tuning, when either is given, win over the
+// {instrument} and {tuning} directives of the content, and those over
+// current; a tuning without an instrument is for the instrument of
+// current. A capo given wins over a {capo} directive. Tab blocks are
+// checked against the resulting tuning.
+func parseSongMetadata(content, instrument, tuning string, capo *uint, current chords.Tuning) (*songMetadata, error) {
+	sheet, err := chords.ParseChordPro(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if instrument == "" && tuning == "" {
+		instrument, tuning = sheet.DirectiveValue("instrument"), sheet.DirectiveValue("tuning")
+	}
+	songTuning := current
+	if instrument != "" || tuning != "" {
+		if instrument == "" {
+			instrument = string(current.Instrument)
+		}
+		if songTuning, err = parseSongTuning(instrument, tuning); err != nil {
+			return nil, err
+		}
+	}
+	if err := sheet.ValidateTab(songTuning); err != nil {
+		return nil, err
+	}
+
+	if capo == nil {
+		if directiveCapo, ok := sheet.Capo(); ok {
+			capo = &directiveCapo
+		}
+	}
+	return &songMetadata{sheet, songTuning, capo}, nil
+}
+
+// Errors of the instrument and tuning of a song or filter. Errors of
+// chords.ParseTuning are wrapped in ErrInvalidTuning.
+var (
+	ErrInvalidInstrument = errors.New("invalid instrument, should be one of [guitar, ukulele, bass, mandolin]")
+	ErrInvalidTuning     = errors.New("invalid tuning")
+)
+
+// parseSongTuning reads the instrument and tuning of a song. An empty
+// instrument is guitar.
+func parseSongTuning(instrument, tuning string) (chords.Tuning, error) {
+	parsed := chords.Guitar
+	if instrument != "" {
+		var err error
+		if parsed, err = chords.ParseInstrument(instrument); err != nil {
+			return chords.Tuning{}, ErrInvalidInstrument
+		}
+	}
+	songTuning, err := chords.ParseTuning(parsed, tuning)
+	if err != nil {
+		return chords.Tuning{}, fmt.Errorf("%w: %w", ErrInvalidTuning, err)
+	}
+	return songTuning, nil
+}
+
+// songTuning returns the tuning the song is played in. Songs stored
+// before instruments and tunings, or with ones no longer known, are
+// played on guitar in standard tuning.
+func songTuning(song *models.Song) chords.Tuning {
+	instrument, err := chords.ParseInstrument(song.Instrument)
+	if err != nil {
+		return chords.StandardGuitar
+	}
+	name := song.Tuning
+	if name == chords.CustomTuning {
+		name = song.TuningNotes
+	}
+	tuning, err := chords.ParseTuning(instrument, name)
+	if err != nil {
+		return chords.StandardGuitar
+	}
+	return tuning
+}
+
+func setSongTuning(song *models.Song, tuning chords.Tuning) {
+	song.Instrument = string(tuning.Instrument)
+	song.Tuning = tuning.Name
+	song.TuningNotes = tuning.Notes()
+}
+
+// detectSongKey sets the song's concert key from the key of its content
+// shifted up by the capo.
+func detectSongKey(song *models.Song) {
+	key, ok := chords.ContentKey(song.Content)
+	if !ok {
+		song.Key, song.Mode = "", ""
+		return
+	}
+	key = key.Transpose(int(song.Capo))
+	song.Key, song.Mode = key.String(), key.Mode()
+}
+
+// Errors of the key and mode filters.
+var (
+	ErrInvalidKey  = errors.New("invalid key")
+	ErrInvalidMode = errors.New("invalid mode, should be one of [major, minor]")
+)
+
+// normalizeKeyFilter spells the key filter the way keys are stored and
+// checks the mode filter.
+func normalizeKeyFilter(key, mode string) (string, string, error) {
+	if key != "" {
+		parsed, err := chords.ParseKey(key)
+		if err != nil {
+			return "", "", ErrInvalidKey
+		}
+		key = parsed.String()
+	}
+	if mode != "" && mode != "major" && mode != "minor" {
+		return "", "", ErrInvalidMode
+	}
+	return key, mode, nil
+}
+
+// normalizeTuningFilter checks the instrument filter and spells the
+// tuning filter the way tunings are stored.
+func normalizeTuningFilter(instrument, tuning string) (string, string, error) {
+	if instrument != "" {
+		parsed, err := chords.ParseInstrument(instrument)
+		if err != nil {
+			return "", "", ErrInvalidInstrument
+		}
+		instrument = string(parsed)
+	}
+	if tuning != "" {
+		tuning = strings.ToLower(strings.NewReplacer(" ", "-", "_", "-").Replace(strings.TrimSpace(tuning)))
+		known := tuning == chords.CustomTuning
+		for _, candidate := range chords.Instruments {
+			known = known || slices.Contains(chords.TuningNames(candidate), tuning)
+		}
+		if !known {
+			return "", "", ErrInvalidTuning
+		}
+	}
+	return instrument, tuning, nil
+}
+
+// ErrInvalidCapo is returned for a capo above chords.MaxCapo.
+var ErrInvalidCapo = fmt.Errorf("invalid capo, should be between 0 and %d", chords.MaxCapo)
+
+// ValidateCapo checks that a capo, when given, fits on the neck.
+func ValidateCapo(capo *uint) error {
+	if capo != nil && *capo > chords.MaxCapo {
+		return ErrInvalidCapo
+	}
+	return nil
+}
+
+// normalizeTags lowercases and trims tag names, dropping empty and
+// duplicate ones.
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// loadSongArtists resolves song-artist links into artists in title order,
+// skipping links whose artist no longer exists.
+func loadSongArtists(artistRepo repositories.ArtistRepository, db *gorm.DB, songArtists []models.SongArtist) []models.Artist {
+	artists := make([]models.Artist, 0, len(songArtists))
+	for _, songArtist := range songArtists {
+		artist, err := artistRepo.GetArtistById(db, songArtist.ArtistID)
+		if err != nil || artist == nil {
+			continue
+		}
+		artists = append(artists, *artist)
+	}
+	return artists
 }
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"chords_app/internal/chords"
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory database with every table the services
+// use.
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to setup test DB: %v", err)
+	}
+	err = db.AutoMigrate(
+		&models.User{}, &models.Artist{}, &models.Song{}, &models.SongArtist{}, &models.Tag{}, &models.SongRequest{},
+		&models.SearchOutbox{}, &models.ImportJob{}, &models.ImportJobFile{},
+	)
+	if err != nil {
+		t.Fatalf("failed to migrate test DB: %v", err)
+	}
+	return db
+}
+
+func setupSongServiceTest(t *testing.T) (*gorm.DB, SongService, ArtistService) {
+	db := newTestDB(t)
+	outboxRepo := repositories.NewGormOutboxRepository()
+	artistRepo := repositories.NewGormArtistRepository()
+	songService := NewSongService(repositories.NewGormSongRepository(), artistRepo, outboxRepo, db)
+	artistService := NewArtistService(artistRepo, outboxRepo, db)
+
+	return db, songService, artistService
+}
+
+func TestUploadSong_RejectsInvalidContent(t *testing.T) {
+	db, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Oasis", "", "")
+
+	_, _, err := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today\n{soc}\n[Xyz]gonna", ArtistIds: []uint{artist.ID}}, 1)
+
+	var parseErrors chords.ParseErrors
+	assert.True(t, errors.As(err, &parseErrors), "expected ParseErrors, got %v", err)
+	assert.Equal(t, "line 2, column 1: start_of_chorus is not closed; line 3, column 2: invalid chord \"Xyz\"", err.Error())
+
+	var count int64
+	db.Model(&models.Song{}).Count(&count)
+	assert.Zero(t, count, "expected invalid song not to be saved")
+}
+
+func TestUpdateSong_ValidatesNewContent(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Oasis", "", "")
+	song, _, err := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[Em7]Today", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+
+	_, _, err = songService.UpdateSong(song.ID, SongInput{Content: "{title: Wonderwall"})
+	var parseErrors chords.ParseErrors
+	assert.True(t, errors.As(err, &parseErrors), "expected ParseErrors, got %v", err)
+
+	updated, _, err := songService.UpdateSong(song.ID, SongInput{Title: "Wonderwall (Live)"})
+	assert.NoError(t, err, "expected update without content to skip validation")
+	assert.Equal(t, "[Em7]Today", updated.Content)
+}
+
+func TestUploadSong_ConvertsChordsOverLyrics(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Kino", "", "")
+
+	pasted := "[Verse 1]\nAm           C\nTeplo mesto, no ulitsy zhdut"
+	preview, err := songService.PreviewImport(pasted)
+	assert.NoError(t, err)
+	assert.True(t, preview.Converted)
+	assert.Equal(t, "{start_of_verse: Verse 1}\n[Am]Teplo mesto, [C]no ulitsy zhdut\n{end_of_verse}", preview.Content)
+
+	song, _, err := songService.UploadSong(SongInput{Title: "Gruppa Krovi", Content: pasted, ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, preview.Content, song.Content)
+	assert.Equal(t, "Am", song.Key)
+
+	preview, err = songService.PreviewImport("[Am]Teplo")
+	assert.NoError(t, err)
+	assert.False(t, preview.Converted, "expected ChordPro to be kept as is")
+}
+
+func TestRenderContent_Transposes(t *testing.T) {
+	_, songService, _ := setupSongServiceTest(t)
+
+	song := models.Song{Content: "[G]Today is [D/F#]gonna be the [Em7]day"}
+	rendered, err := songService.RenderContent(&song, RenderOptions{Transpose: -2})
+	assert.NoError(t, err)
+	assert.Equal(t, "[F]Today is [C/E]gonna be the [Dm7]day", rendered.Content)
+
+	song = models.Song{Content: "[G]legacy [content"}
+	rendered, err = songService.RenderContent(&song, RenderOptions{})
+	assert.NoError(t, err, "expected content to be returned as is without options")
+	assert.Equal(t, "[G]legacy [content", rendered.Content)
+}
+
+func TestUploadSong_ValidatesTabAgainstGuitar(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Oasis", "", "")
+
+	_, _, err := songService.UploadSong(SongInput{Title: "Riff", Content: "[Em]Riff\n{sot}\ne|--0--|\nB|--0--|\nG|--0--|\nD|--2--|\nA|--2--|\nE|--0--|\nB|--0--|\n{eot}", ArtistIds: []uint{artist.ID}}, 1)
+	var parseErrors chords.ParseErrors
+	assert.True(t, errors.As(err, &parseErrors), "expected ParseErrors, got %v", err)
+	assert.EqualError(t, err, "line 3, column 1: tab staff has 7 strings, guitar in standard tuning has 6")
+
+	_, _, err = songService.UploadSong(SongInput{Title: "Riff", Content: "[Em]Riff\n{sot}\ne|--0--|\nB|--0--|\nG|--0--|\nD|--2--|\n{eot}", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err, "expected a staff of the highest four strings to be valid")
+}
+
+func TestUploadSong_Tuning(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Foo Fighters", "", "")
+	dropD := "[D5]Riff\n{sot}\ne|--0--|\nB|--0--|\nG|--0--|\nD|--0--|\nA|--0--|\nD|--0--|\n{eot}"
+
+	_, _, err := songService.UploadSong(SongInput{Title: "Everlong", Content: dropD, ArtistIds: []uint{artist.ID}}, 1)
+	assert.EqualError(t, err, "line 8, column 1: tab string 6 is D, standard tuning has E")
+
+	song, _, err := songService.UploadSong(SongInput{Title: "Everlong", Content: dropD, Tuning: "Drop D", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "guitar", song.Instrument)
+	assert.Equal(t, "drop-d", song.Tuning)
+	assert.Equal(t, "D A D G B E", song.TuningNotes)
+
+	song, _, err = songService.UploadSong(SongInput{Title: "Everlong", Content: "[D]Hello", Instrument: "guitar", Tuning: "C G C F A D", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", song.Tuning)
+	assert.Equal(t, "C G C F A D", song.TuningNotes)
+
+	song, _, err = songService.UploadSong(SongInput{Title: "Everlong", Content: "{instrument: bass}\n{tuning: drop d}\n[D]Hello", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "bass", song.Instrument, "expected the tuning of the directives")
+	assert.Equal(t, "drop-d", song.Tuning)
+
+	_, _, err = songService.UploadSong(SongInput{Title: "Everlong", Content: "[D]Hello", Instrument: "banjo", ArtistIds: []uint{artist.ID}}, 1)
+	assert.EqualError(t, err, "invalid instrument, should be one of [guitar, ukulele, bass, mandolin]")
+	assert.ErrorIs(t, err, ErrInvalidInstrument)
+	_, _, err = songService.UploadSong(SongInput{Title: "Everlong", Content: "[D]Hello", Instrument: "ukulele", Tuning: "dadgad", ArtistIds: []uint{artist.ID}}, 1)
+	assert.ErrorContains(t, err, "invalid tuning: unknown tuning \"dadgad\"")
+	assert.ErrorIs(t, err, ErrInvalidTuning)
+	assert.ErrorIs(t, err, chords.ErrUnknownTuning)
+}
+
+func TestUpdateSong_ValidatesTabAgainstNewTuning(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Oasis", "", "")
+	song, _, err := songService.UploadSong(SongInput{Title: "Riff", Content: "[Em]Riff\n{sot}\ne|--0--|\nB|--0--|\nG|--0--|\nD|--2--|\nA|--2--|\nE|--0--|\n{eot}", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+
+	_, _, err = songService.UpdateSong(song.ID, SongInput{Instrument: "ukulele"})
+	assert.EqualError(t, err, "line 3, column 1: tab staff has 6 strings, ukulele in standard tuning has 4")
+
+	_, _, err = songService.UpdateSong(song.ID, SongInput{Tuning: "half-step-down"})
+	assert.ErrorContains(t, err, "line 8, column 1: tab string 6 is E, half-step-down tuning has no E string")
+
+	updated, _, err := songService.UpdateSong(song.ID, SongInput{Content: "[Em]Riff", Instrument: "ukulele"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ukulele", updated.Instrument)
+	assert.Equal(t, "standard", updated.Tuning)
+
+	updated, _, err = songService.UpdateSong(song.ID, SongInput{Tuning: "half step down"})
+	assert.NoError(t, err, "expected the tuning to apply to the song's instrument")
+	assert.Equal(t, "ukulele", updated.Instrument)
+	assert.Equal(t, "half-step-down", updated.Tuning)
+}
+
+func TestUpdateSong_ReadsDirectivesOfNewContent(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Foo Fighters", "", "")
+	song, _, err := songService.UploadSong(SongInput{Title: "Everlong", Content: "[D]Hello", Instrument: "bass", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+
+	updated, _, err := songService.UpdateSong(song.ID, SongInput{Content: "{tuning: drop-d}\n[D]Hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, "bass", updated.Instrument, "expected the tuning directive to apply to the song's instrument")
+	assert.Equal(t, "drop-d", updated.Tuning)
+
+	updated, _, err = songService.UpdateSong(song.ID, SongInput{Content: "{instrument: ukulele}\n{capo: 3}\n[C]Hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ukulele", updated.Instrument)
+	assert.Equal(t, "standard", updated.Tuning)
+	assert.Equal(t, uint(3), updated.Capo)
+
+	updated, _, err = songService.UpdateSong(song.ID, SongInput{Content: "{instrument: ukulele}\n[C]Hello", Instrument: "mandolin"})
+	assert.NoError(t, err)
+	assert.Equal(t, "mandolin", updated.Instrument, "expected the instrument field to win over the directive")
+
+	_, _, err = songService.UpdateSong(song.ID, SongInput{Content: "{instrument: banjo}\n[C]Hello"})
+	assert.EqualError(t, err, "invalid instrument, should be one of [guitar, ukulele, bass, mandolin]")
+}
+
+func TestRenderContent_TransposesTab(t *testing.T) {
+	_, songService, _ := setupSongServiceTest(t)
+
+	song := models.Song{Content: "[G]Riff\n{start_of_tab}\ne|--3--5--|\n{end_of_tab}"}
+	rendered, err := songService.RenderContent(&song, RenderOptions{Transpose: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "[A]Riff\n{start_of_tab}\ne|--5--7--|\n{end_of_tab}", rendered.Content)
+}
+
+func TestRenderContent_Capo(t *testing.T) {
+	_, songService, _ := setupSongServiceTest(t)
+
+	song := models.Song{Content: "{capo: 2}\n[Em7]Today is [G]gonna be the [Dsus4]day", Capo: 2}
+
+	rendered, err := songService.RenderContent(&song, RenderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), rendered.Capo)
+	assert.Equal(t, song.Content, rendered.Content, "expected the song's own shapes")
+	assert.Equal(t, "[F#m7]Today is [A]gonna be the [Esus4]day", rendered.ConcertContent)
+
+	capo := uint(4)
+	rendered, err = songService.RenderContent(&song, RenderOptions{Capo: &capo})
+	assert.NoError(t, err)
+	assert.Equal(t, "{capo: 4}\n[Dm7]Today is [F]gonna be the [Csus4]day", rendered.Content)
+	assert.Equal(t, "[F#m7]Today is [A]gonna be the [Esus4]day", rendered.ConcertContent, "expected the sounding pitch to stay the same")
+
+	capo = 13
+	_, err = songService.RenderContent(&song, RenderOptions{Capo: &capo})
+	assert.Error(t, err)
+}
+
+func TestRenderContent_Notation(t *testing.T) {
+	_, songService, _ := setupSongServiceTest(t)
+
+	song := models.Song{Content: "{capo: 2}\n[Em]Today is [C]gonna be the [G/B]day", Capo: 2}
+
+	rendered, err := songService.RenderContent(&song, RenderOptions{Notation: chords.NashvilleNotation})
+	assert.NoError(t, err)
+	assert.Equal(t, "{capo: 2}\n[1m]Today is [b6]gonna be the [b3/5]day", rendered.Content)
+	assert.Equal(t, rendered.Content[len("{capo: 2}\n"):], rendered.ConcertContent, "expected numbers not to depend on the capo")
+
+	rendered, err = songService.RenderContent(&song, RenderOptions{Transpose: 3, Notation: chords.RomanNotation})
+	assert.NoError(t, err)
+	assert.Equal(t, "[i]Today is [VI]gonna be the [III/5]day", rendered.ConcertContent, "expected numerals not to depend on the key")
+}
+
+func TestRenderContent_Simplify(t *testing.T) {
+	_, songService, _ := setupSongServiceTest(t)
+
+	song := models.Song{Content: "[Bbmaj7]Some [Ebmaj9]words [F7sus4]and [Gm7]more"}
+
+	rendered, err := songService.RenderContent(&song, RenderOptions{Simplify: true})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), rendered.Capo, "expected the capo that needs no barre chords")
+	assert.Equal(t, "{capo: 3}\n[G]Some [C]words [D]and [Em]more", rendered.Content)
+	assert.Equal(t, "[Bb]Some [Eb]words [F]and [Gm]more", rendered.ConcertContent)
+	assert.Equal(t, DifficultyDTO{chords.DifficultyHard, 2.25}, rendered.Difficulty.Before)
+	assert.Equal(t, DifficultyDTO{chords.DifficultyEasy, 1}, rendered.Difficulty.After)
+
+	capo := uint(0)
+	rendered, err = songService.RenderContent(&song, RenderOptions{Simplify: true, Capo: &capo})
+	assert.NoError(t, err)
+	assert.Equal(t, "[Bb]Some [Eb]words [F]and [Gm]more", rendered.Content, "expected the requested capo to be kept")
+
+	rendered, err = songService.RenderContent(&song, RenderOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, rendered.Difficulty)
+}
+
+func TestUploadSong_TakesCapoFromDirective(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Oasis", "", "")
+
+	song, _, err := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "{capo: 2}\n[Em7]Today", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), song.Capo)
+
+	capo := uint(0)
+	song, _, err = songService.UploadSong(SongInput{Title: "Wonderwall", Content: "{capo: 2}\n[Em7]Today", Capo: &capo, ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+	assert.Zero(t, song.Capo, "expected explicit capo to win over the directive")
+
+	song, _, err = songService.UpdateSong(song.ID, SongInput{Content: "{capo: 4}\n[Em7]Today"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), song.Capo, "expected updated content to set the capo too")
+
+	tooHigh := uint(13)
+	_, _, err = songService.UpdateSong(song.ID, SongInput{Capo: &tooHigh})
+	assert.ErrorIs(t, err, ErrInvalidCapo)
+	assert.EqualError(t, err, "invalid capo, should be between 0 and 12")
+}
+
+func TestUploadSong_DetectsConcertKey(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Kino", "", "")
+
+	song, _, err := songService.UploadSong(SongInput{Title: "Kukushka", Content: "[Am]Pesen [F]eshe [C]nenapisannyh [G]skolko [Am]skazhi", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Am", song.Key)
+	assert.Equal(t, "minor", song.Mode)
+
+	capo := uint(2)
+	song, _, err = songService.UpdateSong(song.ID, SongInput{Capo: &capo})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bm", song.Key, "expected the key to be shifted by the capo")
+}
+
+func TestGetMostPopularSongs_FiltersByKey(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Oasis", "", "")
+
+	wonderwall, _, _ := songService.UploadSong(SongInput{Title: "Wonderwall", Content: "[G]Today is [D]gonna be the [Em]day [C]so [G]today", ArtistIds: []uint{artist.ID}}, 1)
+	songService.UploadSong(SongInput{Title: "Kukushka", Content: "[Am]Pesen [F]eshe [E7]skolko [Am]skazhi", ArtistIds: []uint{artist.ID}}, 1)
+
+	songs, err := songService.GetMostPopularSongs("allTime", "G major", "", "", "", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, *songs, 1)
+	assert.Equal(t, wonderwall.ID, (*songs)[0].ID)
+	assert.Equal(t, "G", (*songs)[0].Key)
+
+	songs, err = songService.GetMostPopularSongs("allTime", "", "minor", "", "", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, *songs, 1)
+	assert.Equal(t, "Am", (*songs)[0].Key)
+
+	_, err = songService.GetMostPopularSongs("allTime", "", "dorian", "", "", 10, 0)
+	assert.Error(t, err)
+}
+
+func TestGetMostPopularSongs_FiltersByTuning(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Foo Fighters", "", "")
+
+	songService.UploadSong(SongInput{Title: "Everlong", Content: "[D]Hello", Tuning: "drop-d", ArtistIds: []uint{artist.ID}}, 1)
+	songService.UploadSong(SongInput{Title: "Times Like These", Content: "[D]I am", Instrument: "bass", Tuning: "drop-d", ArtistIds: []uint{artist.ID}}, 1)
+	songService.UploadSong(SongInput{Title: "Best of You", Content: "[G]I've got", ArtistIds: []uint{artist.ID}}, 1)
+
+	songs, err := songService.GetMostPopularSongs("allTime", "", "", "", "Drop D", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, *songs, 2)
+
+	songs, err = songService.GetMostPopularSongs("allTime", "", "", "guitar", "drop-d", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, *songs, 1) {
+		assert.Equal(t, "Everlong", (*songs)[0].Title)
+		assert.Equal(t, "drop-d", (*songs)[0].Tuning)
+	}
+
+	_, err = songService.GetMostPopularSongs("allTime", "", "", "banjo", "", 10, 0)
+	assert.Error(t, err)
+	_, err = songService.GetMostPopularSongs("allTime", "", "", "", "open-g", 10, 0)
+	assert.EqualError(t, err, "invalid tuning")
+}
+
+func TestGetArtistInformation_ListsSongDetails(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	foo, _ := artistService.CreateArtist("Foo Fighters", "", "")
+	dave, _ := artistService.CreateArtist("Dave Grohl", "", "")
+
+	song, _, err := songService.UploadSong(SongInput{Title: "Everlong", Content: "[D]Hello [G]I've waited [D]here", Tuning: "drop-d", ArtistIds: []uint{foo.ID, dave.ID}}, 1)
+	assert.NoError(t, err)
+
+	_, songs, err := artistService.GetArtistInformation(foo.ID)
+	assert.NoError(t, err)
+	expected := SongDTO{
+		ID:         song.ID,
+		Title:      "Everlong",
+		Key:        "D",
+		Mode:       "major",
+		Instrument: "guitar",
+		Tuning:     "drop-d",
+		Artists:    []ArtistDTO{{foo.ID, "Foo Fighters"}, {dave.ID, "Dave Grohl"}},
+	}
+	assert.Equal(t, []SongDTO{expected}, *songs)
+
+	popular, err := songService.GetMostPopularSongs("allTime", "", "", "", "", 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []SongDTOWithViews{{expected, 0}}, *popular, "expected listings and the artist page to agree")
+}
+
+func TestBackfillSongKeys(t *testing.T) {
+	db, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Kino", "", "")
+
+	legacy := models.Song{Title: "Kukushka", Content: "[Am]Pesen [F]eshe [E7]skolko [Am]skazhi", Capo: 2}
+	db.Create(&legacy)
+	db.Create(&models.Song{Title: "Poem", Content: "No chords at all"})
+	songService.UploadSong(SongInput{Title: "Gruppa Krovi", Content: "[Am]Teplo", ArtistIds: []uint{artist.ID}}, 1)
+
+	updated, err := songService.BackfillSongKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updated, "expected only the legacy song with chords to be updated")
+
+	songs, err := songService.GetMostPopularSongs("allTime", "Bm", "", "", "", 10, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, *songs, 1) {
+		assert.Equal(t, legacy.ID, (*songs)[0].ID, "expected the concert key with the capo")
+	}
+
+	updated, _ = songService.BackfillSongKeys()
+	assert.Zero(t, updated, "expected a second run to find nothing")
+}
+
+func TestGetSongChords_DistinctShapesWithVoicings(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Kino", "", "")
+	capo := uint(2)
+	song, _, err := songService.UploadSong(SongInput{Title: "Gruppa Krovi", Content: "[Am]Teplo [F]mesto [Am]no [G]ulitsy", Capo: &capo, ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+
+	songChords, err := songService.GetSongChords(song.ID, "guitar", RenderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), songChords.Capo)
+	assert.Equal(t, []string{"E", "A", "D", "G", "B", "E"}, songChords.Tuning)
+
+	symbols := make([]string, 0, len(songChords.Chords))
+	for _, chord := range songChords.Chords {
+		symbols = append(symbols, chord.Symbol)
+		assert.NotEmpty(t, chord.Voicings, chord.Symbol)
+	}
+	assert.Equal(t, []string{"Am", "F", "G"}, symbols)
+	assert.Equal(t, []int{-1, 0, 2, 2, 1, 0}, songChords.Chords[0].Voicings[0].Frets)
+
+	zero := uint(0)
+	songChords, err = songService.GetSongChords(song.ID, "ukulele", RenderOptions{Capo: &zero})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bm", songChords.Chords[0].Symbol, "expected concert chords without a capo")
+	assert.Len(t, songChords.Chords[0].Voicings[0].Frets, 4)
+
+	_, err = songService.GetSongChords(song.ID, "banjo", RenderOptions{})
+	assert.EqualError(t, err, "invalid instrument, should be one of [guitar, ukulele, bass, mandolin]")
+}
+
+func TestGetSongChords_UsesSongTuning(t *testing.T) {
+	_, songService, artistService := setupSongServiceTest(t)
+	artist, _ := artistService.CreateArtist("Foo Fighters", "", "")
+	song, _, err := songService.UploadSong(SongInput{Title: "Everlong", Content: "[D]Hello", Tuning: "drop-d", ArtistIds: []uint{artist.ID}}, 1)
+	assert.NoError(t, err)
+
+	songChords, err := songService.GetSongChords(song.ID, "", RenderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "drop-d", songChords.TuningName)
+	assert.Equal(t, []string{"D", "A", "D", "G", "B", "E"}, songChords.Tuning)
+	assert.Equal(t, []int{0, 0, 0, 2, 3, 2}, songChords.Chords[0].Voicings[0].Frets)
+
+	songChords, err = songService.GetSongChords(song.ID, "mandolin", RenderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, chords.Mandolin, songChords.Instrument)
+	assert.Equal(t, "standard", songChords.TuningName, "expected another instrument in its standard tuning")
+}
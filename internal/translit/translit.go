@@ -0,0 +1,52 @@
+// Package translit converts Cyrillic text to Latin letters the way people
+// usually type Russian words on a Latin keyboard ("Группа крови" becomes
+// "Gruppa krovi").
+package translit
+
+import (
+	"strings"
+	"unicode"
+)
+
+// latin maps lowercase Cyrillic letters to their Latin spelling. Letters
+// without a sound of their own are dropped.
+var latin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "h", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	// Ukrainian and Belarusian letters.
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// ToLatin transliterates Cyrillic letters and leaves everything else
+// untouched. Capitalised letters stay capitalised ("Щ" becomes "Sch").
+func ToLatin(s string) string {
+	var builder strings.Builder
+	builder.Grow(len(s))
+
+	for _, r := range s {
+		lower := unicode.ToLower(r)
+		replacement, ok := latin[lower]
+		if !ok {
+			builder.WriteRune(r)
+			continue
+		}
+		if r != lower && replacement != "" {
+			replacement = strings.ToUpper(replacement[:1]) + replacement[1:]
+		}
+		builder.WriteString(replacement)
+	}
+	return builder.String()
+}
+
+// HasCyrillic reports whether s contains any Cyrillic letter.
+func HasCyrillic(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
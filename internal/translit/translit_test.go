@@ -0,0 +1,39 @@
+package translit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToLatin(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Кино", "Kino"},
+		{"Группа крови", "Gruppa krovi"},
+		{"Звезда по имени Солнце", "Zvezda po imeni Solntse"},
+		{"Ёлки-палки", "Elki-palki"},
+		{"Жёлтая подводная лодка", "Zheltaya podvodnaya lodka"},
+		{"Щука и ёж", "Schuka i ezh"},
+		{"Объявление", "Obyavlenie"},
+		{"Чайф", "Chayf"},
+		{"Хочу перемен!", "Hochu peremen!"},
+		{"Їжак", "Yizhak"},
+		{"Oasis", "Oasis"},
+		{"ДДТ 1987", "DDT 1987"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ToLatin(tt.input))
+		})
+	}
+}
+
+func TestHasCyrillic(t *testing.T) {
+	assert.True(t, HasCyrillic("Кино"))
+	assert.True(t, HasCyrillic("Би-2 feat. Oxxxymiron"))
+	assert.False(t, HasCyrillic("Oasis"))
+}
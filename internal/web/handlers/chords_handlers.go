@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"chords_app/internal/services"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChordHandler struct {
+	service services.ChordService
+}
+
+func NewChordHandlers(service services.ChordService) *ChordHandler {
+	return &ChordHandler{service}
+}
+
+// GetChordDiagram serves /chords/*symbol where symbol ends with .svg. The
+// catch-all parameter lets slash chords like G/B through unescaped; sharps
+// have to be sent as %23.
+func (h *ChordHandler) GetChordDiagram(c *gin.Context) {
+	symbol, ok := strings.CutSuffix(strings.TrimPrefix(c.Param("symbol"), "/"), ".svg")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "diagram should be requested as `.svg`"})
+		return
+	}
+
+	variant, err := parseUintQueryParam(c, "voicing", 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `voicing` parameter. It should be non negative integer"})
+		return
+	}
+
+	svg, err := h.service.GetDiagramSVG(symbol, c.Query("instrument"), c.Query("tuning"), variant)
+	if err != nil {
+		var statusCode int
+		switch {
+		case err.Error() == "voicing not found":
+			statusCode = http.StatusNotFound
+		case err.Error() == "invalid chord" || isTuningError(err):
+			statusCode = http.StatusBadRequest
+		default:
+			statusCode = http.StatusInternalServerError
+		}
+		c.JSON(statusCode, gin.H{"error": err.Error()})
+		return
+	}
+
+	sum := sha256.Sum256(svg)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=86400")
+	if ifNoneMatch(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "image/svg+xml", svg)
+}
+
+// ifNoneMatch reports whether the If-None-Match header lists the ETag.
+func ifNoneMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
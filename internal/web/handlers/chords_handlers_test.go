@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"chords_app/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupChordRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handler := NewChordHandlers(services.NewChordService())
+	r.GET("/chords/*symbol", handler.GetChordDiagram)
+	return r
+}
+
+func getDiagram(r *gin.Engine, url, etag string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetChordDiagram_ServesSVGWithETag(t *testing.T) {
+	r := setupChordRouter()
+
+	w := getDiagram(r, "/chords/F%23m.svg", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<title>F#m</title>")
+
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	w = getDiagram(r, "/chords/F%23m.svg", etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = getDiagram(r, "/chords/F%23m.svg?instrument=ukulele", etag)
+	assert.Equal(t, http.StatusOK, w.Code, "expected a different diagram for ukulele")
+
+	w = getDiagram(r, "/chords/D.svg?tuning=drop-d", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, getDiagram(r, "/chords/D.svg", "").Body.String(), w.Body.String(), "expected a drop D voicing")
+}
+
+func TestGetChordDiagram_SlashChord(t *testing.T) {
+	w := getDiagram(setupChordRouter(), "/chords/G/B.svg", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<title>G/B</title>")
+}
+
+func TestGetChordDiagram_InvalidRequests(t *testing.T) {
+	r := setupChordRouter()
+
+	assert.Equal(t, http.StatusBadRequest, getDiagram(r, "/chords/Xyz.svg", "").Code)
+	assert.Equal(t, http.StatusBadRequest, getDiagram(r, "/chords/Am.svg?instrument=banjo", "").Code)
+	assert.Equal(t, http.StatusBadRequest, getDiagram(r, "/chords/Am.svg?tuning=open-g", "").Code)
+	assert.Equal(t, http.StatusBadRequest, getDiagram(r, "/chords/Am.svg?voicing=-1", "").Code)
+	assert.Equal(t, http.StatusNotFound, getDiagram(r, "/chords/Am.svg?voicing=1000", "").Code)
+	assert.Equal(t, http.StatusNotFound, getDiagram(r, "/chords/Am.png", "").Code)
+}
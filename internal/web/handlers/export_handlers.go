@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"chords_app/internal/chords"
+	"chords_app/internal/services"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	service services.ExportService
+}
+
+func NewExportHandlers(service services.ExportService) *ExportHandler {
+	return &ExportHandler{service}
+}
+
+func (h *ExportHandler) ExportSong(c *gin.Context) {
+	songId, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid song ID"})
+		return
+	}
+
+	file, err := h.service.ExportSong(songId, c.Query("format"))
+	if err != nil {
+		var parseErrors chords.ParseErrors
+		switch {
+		case errors.As(err, &parseErrors):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "song content is not valid ChordPro and cannot be exported"})
+		case err.Error() == "song not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidFormat):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+file.Filename+`"`)
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+func (h *ExportHandler) ExportMySongs(c *gin.Context) {
+	user, exists := GetUserModel(c)
+	if !exists {
+		return
+	}
+	h.exportSongs(c, &user.ID)
+}
+
+// ExportAllSongs exports every song, or those of the uploadedBy user.
+func (h *ExportHandler) ExportAllSongs(c *gin.Context) {
+	var uploadedBy *uint
+	if c.Query("uploadedBy") != "" {
+		userId, err := parseUintQueryParam(c, "uploadedBy", 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid uploadedBy"})
+			return
+		}
+		uploadedBy = &userId
+	}
+	h.exportSongs(c, uploadedBy)
+}
+
+// exportSongs streams the archive as it is written. Once the first bytes
+// are sent an error can no longer be reported in the response, the
+// client gets a truncated archive instead.
+func (h *ExportHandler) exportSongs(c *gin.Context, uploadedBy *uint) {
+	c.Header("Content-Disposition", `attachment; filename="`+h.service.BackupFilename(uploadedBy)+`"`)
+	c.Header("Content-Type", "application/zip")
+
+	if err := h.service.ExportSongs(c.Writer, uploadedBy); err != nil {
+		if c.Writer.Written() {
+			slog.Error("failed to export songs", slog.String("error", err.Error()))
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Del("Content-Disposition")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"chords_app/internal/models"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -45,6 +46,39 @@ func parseUintQueryParam(c *gin.Context, param string, defaultValue uint) (uint,
 	return uint(value), nil
 }
 
+func parseIntQueryParam(c *gin.Context, param string, defaultValue int) (int, error) {
+	paramStr := c.Query(param)
+	if paramStr == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.ParseInt(paramStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return int(value), nil
+}
+
+// parseUintListQueryParam reads a parameter that may be repeated and/or
+// hold a comma separated list of IDs.
+func parseUintListQueryParam(c *gin.Context, param string) ([]uint, error) {
+	values := make([]uint, 0)
+	for _, paramStr := range c.QueryArray(param) {
+		for _, part := range strings.Split(paramStr, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			value, err := strconv.ParseUint(part, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, uint(value))
+		}
+	}
+	return values, nil
+}
+
 func GetUserModel(c *gin.Context) (*models.User, bool) {
 	user, exists := c.Get("user")
 	if !exists {
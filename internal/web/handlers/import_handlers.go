@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"chords_app/internal/services"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportArchiveSize bounds uploaded archives, as the job keeps them in
+// the database until it finishes. The request body may be a little larger
+// for the multipart headers.
+const (
+	maxImportArchiveSize = 64 << 20
+	maxImportRequestSize = maxImportArchiveSize + 1<<20
+)
+
+type ImportHandler struct {
+	service services.ImportService
+}
+
+func NewImportHandlers(service services.ImportService) *ImportHandler {
+	return &ImportHandler{service}
+}
+
+func (h *ImportHandler) CreateImportJob(c *gin.Context) {
+	user, exists := GetUserModel(c)
+	if !exists {
+		return
+	}
+
+	// Without the limit the multipart form would be spooled to disk
+	// whatever its size before the archive size could be checked.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportRequestSize)
+	header, err := c.FormFile("archive")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "archive is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "archive file is required"})
+		return
+	}
+	if header.Size > maxImportArchiveSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "archive is too large"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.service.CreateJob(header.Filename, data, user.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidArchive) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *ImportHandler) GetImportJob(c *gin.Context) {
+	jobId, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid import job ID"})
+		return
+	}
+
+	job, err := h.service.GetJob(jobId)
+	if err != nil {
+		if err.Error() == "import job not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
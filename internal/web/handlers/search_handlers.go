@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"chords_app/internal/chords"
+	"chords_app/internal/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxSuggestions = 20
+
+type SearchHandler struct {
+	service services.SearchService
+}
+
+func NewSearchHandlers(service services.SearchService) *SearchHandler {
+	return &SearchHandler{service}
+}
+
+func (h *SearchHandler) Search(c *gin.Context) {
+	artistIds, err := parseUintListQueryParam(c, "artistId")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `artistId` parameter. It should be a list of artist IDs"})
+		return
+	}
+
+	uploadedBy, err := parseUintQueryParam(c, "uploadedBy", 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `uploadedBy` parameter. It should be user ID"})
+		return
+	}
+
+	filters := services.SearchFilters{
+		ArtistIds:  artistIds,
+		Key:        strings.TrimSpace(c.Query("key")),
+		Mode:       strings.TrimSpace(c.Query("mode")),
+		Instrument: strings.TrimSpace(c.Query("instrument")),
+		Tuning:     strings.TrimSpace(c.Query("tuning")),
+		Difficulty: strings.TrimSpace(c.Query("difficulty")),
+		Tag:        strings.TrimSpace(c.Query("tag")),
+		UploadedBy: uploadedBy,
+	}
+
+	limit, err := parseUintQueryParam(c, "limit", 20)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `limit` parameter. It should be non negative integer"})
+		return
+	}
+
+	offset, err := parseUintQueryParam(c, "offset", 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `offset` parameter. It should be non negative integer"})
+		return
+	}
+
+	results, err := h.service.Search(strings.TrimSpace(c.Query("q")), filters, limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrQueryRequired) || errors.Is(err, services.ErrInvalidKey) ||
+			errors.Is(err, services.ErrInvalidMode) || errors.Is(err, services.ErrInvalidDifficulty) || isTuningError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"songs":   results.Songs,
+		"artists": results.Artists,
+		"facets":  results.Facets,
+	})
+}
+
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusOK, gin.H{"suggestions": []services.SuggestionDTO{}})
+		return
+	}
+
+	limit, err := parseUintQueryParam(c, "limit", 10)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `limit` parameter. It should be non negative integer"})
+		return
+	}
+	if limit > maxSuggestions {
+		limit = maxSuggestions
+	}
+
+	suggestions, err := h.service.Suggest(query, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
+
+func (h *SearchHandler) SearchProgression(c *gin.Context) {
+	chordSymbols := make([]string, 0)
+	for _, symbol := range strings.Split(c.Query("chords"), ",") {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			chordSymbols = append(chordSymbols, symbol)
+		}
+	}
+	if len(chordSymbols) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "`chords` parameter is required"})
+		return
+	}
+	for _, symbol := range chordSymbols {
+		if !chords.IsChord(symbol) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid chord %q", symbol)})
+			return
+		}
+	}
+
+	limit, err := parseUintQueryParam(c, "limit", 20)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `limit` parameter. It should be non negative integer"})
+		return
+	}
+
+	offset, err := parseUintQueryParam(c, "offset", 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `offset` parameter. It should be non negative integer"})
+		return
+	}
+
+	songs, err := h.service.SearchProgression(chordSymbols, limit, offset)
+	if err != nil {
+		if errors.Is(err, services.ErrProgressionTooShort) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"songs": songs})
+}
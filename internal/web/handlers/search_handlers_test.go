@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"chords_app/internal/adapters/opensearch"
+	"chords_app/internal/models"
+	"chords_app/internal/repositories"
+	"chords_app/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type searchResponse struct {
+	Songs   []services.SongHitDTO
+	Artists []services.ArtistHitDTO
+	Facets  map[string][]services.FacetBucketDTO
+}
+
+func setupSearchRouter(t *testing.T) (*gin.Engine, *gorm.DB, *opensearch.InMemoryAdapter) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to setup test DB: %v", err)
+	}
+	err = db.AutoMigrate(&models.User{}, &models.Artist{}, &models.Song{}, &models.SongArtist{}, &models.Tag{})
+	if err != nil {
+		t.Fatalf("failed to migrate test DB: %v", err)
+	}
+
+	adapter := opensearch.NewInMemoryAdapter()
+	service := services.NewSearchService(
+		adapter,
+		repositories.NewGormSongRepository(),
+		repositories.NewGormArtistRepository(),
+		db,
+	)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	handler := NewSearchHandlers(service)
+	r.GET("/search", handler.Search)
+	r.GET("/search/suggest", handler.Suggest)
+	r.GET("/search/progression", handler.SearchProgression)
+
+	return r, db, adapter
+}
+
+func createIndexedSong(t *testing.T, db *gorm.DB, adapter *opensearch.InMemoryAdapter, title string, artist *models.Artist) *models.Song {
+	song := models.Song{Title: title, Content: "[Am]some [F]lyrics"}
+	if err := db.Create(&song).Error; err != nil {
+		t.Fatalf("failed to create song: %v", err)
+	}
+	if err := db.Create(&models.SongArtist{ArtistID: artist.ID, SongID: song.ID}).Error; err != nil {
+		t.Fatalf("failed to attach artist: %v", err)
+	}
+	adapter.IndexSong(&song, []models.Artist{*artist})
+	return &song
+}
+
+// doGet requests the url and decodes the JSON response into T.
+func doGet[T any](r *gin.Engine, url string) (*httptest.ResponseRecorder, T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	r.ServeHTTP(w, req)
+
+	var body T
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w, body
+}
+
+func TestSearch_GroupsResultsByType(t *testing.T) {
+	r, db, adapter := setupSearchRouter(t)
+
+	artist := models.Artist{Name: "Oasis"}
+	db.Create(&artist)
+	adapter.IndexArtist(&artist)
+	song := createIndexedSong(t, db, adapter, "Wonderwall", &artist)
+	createIndexedSong(t, db, adapter, "Champagne Supernova", &artist)
+
+	w, body := doGet[searchResponse](r, "/search?q=wonderwall")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, body.Songs, 1, "expected one song")
+	assert.Empty(t, body.Artists, "expected no artists")
+	assert.Equal(t, song.ID, body.Songs[0].ID)
+	assert.Equal(t, []services.ArtistDTO{{ID: artist.ID, Name: "Oasis"}}, body.Songs[0].Artists)
+
+	w, body = doGet[searchResponse](r, "/search?q=oasis")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, body.Artists, 1, "expected one artist")
+	assert.Equal(t, "Oasis", body.Artists[0].Name)
+	assert.Equal(t, map[string][]string{"name": {"<em>Oasis</em>"}}, body.Artists[0].Highlights)
+}
+
+func TestSearch_ReturnsHighlightedSnippets(t *testing.T) {
+	r, db, adapter := setupSearchRouter(t)
+
+	createSongWithContent(t, db, adapter, "Wonderwall",
+		"[Em7]Today is [G]gonna be the day\n[Dsus4]That they're gonna throw it back to you\n[Cadd9]Maybe you're gonna be the one that saves me")
+
+	w, body := doGet[searchResponse](r, "/search?q=saves")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, body.Songs, 1, "expected one song")
+	assert.Equal(t, []string{"Maybe you&#39;re gonna be the one that <em>saves</em> me"}, body.Songs[0].Highlights["lyrics"])
+	assert.NotContains(t, body.Songs[0].Highlights, "title", "expected no title snippet without a title match")
+}
+
+func TestSearch_FiltersAndFacets(t *testing.T) {
+	r, db, adapter := setupSearchRouter(t)
+
+	kino := models.Artist{Name: "Kino"}
+	db.Create(&kino)
+	adapter.IndexArtist(&kino)
+
+	easy := createIndexedSong(t, db, adapter, "Kukushka", &kino)
+	easy.Content = "[Am]Pesen [C]eshe [G]nenapisannyh [Em]skolko [Am]skazhi"
+	easy.UploadedBy = 7
+	easy.Tags = []models.Tag{{Name: "rock"}, {Name: "ballad"}}
+	adapter.IndexSong(easy, []models.Artist{kino})
+
+	hard := createIndexedSong(t, db, adapter, "Pachka sigaret", &kino)
+	hard.Content = "[Bbm7]Ya [Ebm9]sizhu [Ab7b9]i [Dbmaj7]smotryu"
+	hard.Tags = []models.Tag{{Name: "rock"}}
+	adapter.IndexSong(hard, []models.Artist{kino})
+
+	w, body := doGet[searchResponse](r, "/search?q=kino")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, body.Songs, 2, "expected both songs without filters")
+	assert.Len(t, body.Artists, 1)
+	assert.Contains(t, body.Facets[opensearch.FacetTag], services.FacetBucketDTO{Value: "rock", Count: 2})
+	assert.Contains(t, body.Facets[opensearch.FacetArtist], services.FacetBucketDTO{Value: fmt.Sprint(kino.ID), Label: "Kino", Count: 2})
+
+	_, body = doGet[searchResponse](r, "/search?q=kino&difficulty=easy")
+	assert.Len(t, body.Songs, 1, "expected difficulty filter to be applied")
+	assert.Equal(t, easy.ID, body.Songs[0].ID)
+	assert.Empty(t, body.Artists, "expected filters to exclude artists")
+	assert.Equal(t, []services.FacetBucketDTO{{Value: "Am", Count: 1}}, body.Facets[opensearch.FacetKey])
+
+	_, body = doGet[searchResponse](r, "/search?tag=Ballad&uploadedBy=7")
+	assert.Len(t, body.Songs, 1, "expected filters to work without a query")
+	assert.Equal(t, easy.ID, body.Songs[0].ID)
+
+	_, body = doGet[searchResponse](r, fmt.Sprintf("/search?key=A+minor&artistId=%d,%d", kino.ID, kino.ID+1))
+	assert.Len(t, body.Songs, 1, "expected key filter to accept spelled out modes")
+
+	w, _ = doGet[searchResponse](r, "/search?q=kino&difficulty=impossible")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = doGet[searchResponse](r, "/search?q=kino&key=X")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSearch_MatchesAcrossScripts(t *testing.T) {
+	r, db, adapter := setupSearchRouter(t)
+
+	kino := models.Artist{Name: "Кино"}
+	db.Create(&kino)
+	adapter.IndexArtist(&kino)
+	song := createIndexedSong(t, db, adapter, "Группа крови", &kino)
+
+	_, body := doGet[searchResponse](r, "/search?q=kino")
+	assert.Len(t, body.Artists, 1, "expected Latin query to find Cyrillic artist")
+	assert.Equal(t, kino.ID, body.Artists[0].ID)
+
+	_, body = doGet[searchResponse](r, "/search?q=gruppa+krovi")
+	assert.Len(t, body.Songs, 1, "expected Latin query to find Cyrillic title")
+	assert.Equal(t, song.ID, body.Songs[0].ID)
+
+	w, suggestions := doGet[suggestResponse](r, "/search/suggest?q=grup")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, suggestions.Suggestions, 1, "expected Latin prefix to suggest Cyrillic title")
+	assert.Equal(t, "Группа крови", suggestions.Suggestions[0].Text)
+}
+
+func TestSearch_LimitAndOffset(t *testing.T) {
+	r, db, adapter := setupSearchRouter(t)
+
+	artist := models.Artist{Name: "Kino"}
+	db.Create(&artist)
+	createIndexedSong(t, db, adapter, "Song One", &artist)
+	createIndexedSong(t, db, adapter, "Song Two", &artist)
+	createIndexedSong(t, db, adapter, "Song Three", &artist)
+
+	_, body := doGet[searchResponse](r, "/search?q=song&limit=2")
+	assert.Len(t, body.Songs, 2, "expected limit to be applied")
+
+	_, body = doGet[searchResponse](r, "/search?q=song&limit=2&offset=2")
+	assert.Len(t, body.Songs, 1, "expected offset to be applied")
+}
+
+func TestSearch_SkipsStaleDocuments(t *testing.T) {
+	r, db, adapter := setupSearchRouter(t)
+
+	artist := models.Artist{Name: "Kino"}
+	db.Create(&artist)
+	song := createIndexedSong(t, db, adapter, "Gruppa Krovi", &artist)
+	db.Delete(song)
+
+	w, body := doGet[searchResponse](r, "/search?q=krovi")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, body.Songs, "expected deleted song to be skipped")
+}
+
+func TestSearch_InvalidParams(t *testing.T) {
+	r, _, _ := setupSearchRouter(t)
+
+	w, _ := doGet[searchResponse](r, "/search")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = doGet[searchResponse](r, "/search?q=song&limit=-1")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = doGet[searchResponse](r, "/search?q=song&offset=abc")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+type suggestResponse struct {
+	Suggestions []services.SuggestionDTO
+}
+
+type stubSuggestAdapter struct {
+	*opensearch.InMemoryAdapter
+	lastPrefix string
+	lastLimit  uint
+}
+
+func (a *stubSuggestAdapter) Suggest(prefix string, limit uint) ([]opensearch.Suggestion, error) {
+	a.lastPrefix = prefix
+	a.lastLimit = limit
+	return []opensearch.Suggestion{{ObjType: opensearch.SongType, ObjId: 7, Text: "Wonderwall"}}, nil
+}
+
+func TestSuggest_MatchesTitlesAndNamesByPrefix(t *testing.T) {
+	r, db, adapter := setupSearchRouter(t)
+
+	artist := models.Artist{Name: "Kino"}
+	db.Create(&artist)
+	adapter.IndexArtist(&artist)
+	song := createIndexedSong(t, db, adapter, "Gruppa Krovi", &artist)
+	createIndexedSong(t, db, adapter, "Kukushka", &artist)
+
+	w, body := doGet[suggestResponse](r, "/search/suggest?q=gruppa%20kr")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []services.SuggestionDTO{{ID: song.ID, Type: opensearch.SongType, Text: "Gruppa Krovi"}}, body.Suggestions)
+
+	_, body = doGet[suggestResponse](r, "/search/suggest?q=k")
+	assert.Len(t, body.Suggestions, 3, "expected artist and both songs")
+	assert.Equal(t, opensearch.ArtistType, body.Suggestions[1].Type)
+
+	_, body = doGet[suggestResponse](r, "/search/suggest?q=k&limit=1")
+	assert.Len(t, body.Suggestions, 1, "expected limit to be applied")
+}
+
+func TestSuggest_StubbedAdapter(t *testing.T) {
+	adapter := &stubSuggestAdapter{InMemoryAdapter: opensearch.NewInMemoryAdapter()}
+	service := services.NewSearchService(adapter, nil, nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/search/suggest", NewSearchHandlers(service).Suggest)
+
+	w, body := doGet[suggestResponse](r, "/search/suggest?q=%20won%20&limit=100")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "won", adapter.lastPrefix, "expected query to be trimmed")
+	assert.Equal(t, uint(maxSuggestions), adapter.lastLimit, "expected limit to be capped")
+	assert.Equal(t, []services.SuggestionDTO{{ID: 7, Type: opensearch.SongType, Text: "Wonderwall"}}, body.Suggestions)
+
+	adapter.lastPrefix = ""
+	w, body = doGet[suggestResponse](r, "/search/suggest?q=")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, body.Suggestions)
+	assert.Empty(t, adapter.lastPrefix, "expected empty query not to reach the adapter")
+
+	w, _ = doGet[suggestResponse](r, "/search/suggest?q=won&limit=abc")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func createSongWithContent(t *testing.T, db *gorm.DB, adapter *opensearch.InMemoryAdapter, title, content string) *models.Song {
+	artist := models.Artist{Name: "Artist of " + title}
+	db.Create(&artist)
+	song := models.Song{Title: title, Content: content}
+	if err := db.Create(&song).Error; err != nil {
+		t.Fatalf("failed to create song: %v", err)
+	}
+	if err := db.Create(&models.SongArtist{ArtistID: artist.ID, SongID: song.ID}).Error; err != nil {
+		t.Fatalf("failed to attach artist: %v", err)
+	}
+	adapter.IndexSong(&song, []models.Artist{artist})
+	return &song
+}
+
+func TestSearchProgression_MatchesInAnyKey(t *testing.T) {
+	r, db, adapter := setupSearchRouter(t)
+
+	inG := createSongWithContent(t, db, adapter, "In G", "[Em]one [C]two [G]three [D]four [Em]one [C]two [G]three [D]four")
+	inA := createSongWithContent(t, db, adapter, "In A minor", "[Am]one [F]two [C]three [G]four [Dm]five [E]six")
+	createSongWithContent(t, db, adapter, "Blues", "[A7]one [D7]two [A7]three [E7]four")
+
+	w, body := doGet[searchResponse](r, "/search/progression?chords=Am,F,C,G")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, body.Songs, 2, "expected the progression to match in both keys")
+	assert.Equal(t, inG.ID, body.Songs[0].ID, "expected the song built on the progression to rank first")
+	assert.Equal(t, inA.ID, body.Songs[1].ID)
+}
+
+func TestSearchProgression_InvalidChords(t *testing.T) {
+	r, _, _ := setupSearchRouter(t)
+
+	w, _ := doGet[searchResponse](r, "/search/progression")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = doGet[searchResponse](r, "/search/progression?chords=Am,Xyz")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, body := doGet[gin.H](r, "/search/progression?chords=Am,Am")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, services.ErrProgressionTooShort.Error(), body["error"])
+}
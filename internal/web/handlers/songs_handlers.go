@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"chords_app/internal/chords"
 	"chords_app/internal/config"
+	"chords_app/internal/models"
 	"chords_app/internal/services"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -37,8 +41,12 @@ func (h *SongHandler) GetMostPopularSongs(c *gin.Context) {
 		period = "allTime"
 	}
 
-	songs, err := h.service.GetMostPopularSongs(period, limit, offset)
+	songs, err := h.service.GetMostPopularSongs(period, c.Query("key"), c.Query("mode"), c.Query("instrument"), c.Query("tuning"), limit, offset)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidKey) || errors.Is(err, services.ErrInvalidMode) || isTuningError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -55,6 +63,19 @@ func (h *SongHandler) GetSong(c *gin.Context) {
 		return
 	}
 
+	options, ok := parseRenderOptions(c)
+	if !ok {
+		return
+	}
+	if c.Query("notation") != "" {
+		notation, err := chords.ParseNotation(c.Query("notation"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `notation` parameter. It should be one of [chords, nashville, roman]"})
+			return
+		}
+		options.Notation = notation
+	}
+
 	song, err := h.service.GetSongWithArtists(songId)
 	if err != nil {
 		var statusCode int
@@ -67,20 +88,93 @@ func (h *SongHandler) GetSong(c *gin.Context) {
 		return
 	}
 
+	rendered, err := h.service.RenderContent(song, options)
+	if err != nil {
+		var parseErrors chords.ParseErrors
+		if errors.As(err, &parseErrors) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "song content is not valid ChordPro and cannot be rendered"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	artistIds := make([]uint, 0, len(song.Artists))
 	for _, artist := range song.Artists {
 		artistIds = append(artistIds, artist.ID)
 	}
 
+	response := gin.H{
+		"id":             song.ID,
+		"title":          song.Title,
+		"description":    song.Description,
+		"content":        rendered.Content,
+		"concertContent": rendered.ConcertContent,
+		"capo":           rendered.Capo,
+		"key":            song.Key,
+		"mode":           song.Mode,
+		"instrument":     song.Instrument,
+		"tuning":         song.Tuning,
+		"tuningNotes":    song.TuningNotes,
+		"uploadedBy":     song.UploadedBy,
+		"artistIds":      artistIds,
+		"tags":           tagNames(song.Tags),
+	}
+	if difficulty := rendered.Difficulty; difficulty != nil {
+		response["difficulty"] = gin.H{
+			"before": gin.H{"level": difficulty.Before.Level, "score": difficulty.Before.Score},
+			"after":  gin.H{"level": difficulty.After.Level, "score": difficulty.After.Score},
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *SongHandler) GetSongChords(c *gin.Context) {
+	songId, err := parseUintParam(c, "id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid song ID"})
+		return
+	}
+
+	options, ok := parseRenderOptions(c)
+	if !ok {
+		return
+	}
+
+	songChords, err := h.service.GetSongChords(songId, c.Query("instrument"), options)
+	if err != nil {
+		var parseErrors chords.ParseErrors
+		switch {
+		case errors.As(err, &parseErrors):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "song content is not valid ChordPro and cannot be rendered"})
+		case err.Error() == "song not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidInstrument):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	chordList := make([]gin.H, 0, len(songChords.Chords))
+	for _, chord := range songChords.Chords {
+		voicings := make([]gin.H, 0, len(chord.Voicings))
+		for _, voicing := range chord.Voicings {
+			voicings = append(voicings, voicingJSON(voicing))
+		}
+		chordList = append(chordList, gin.H{"symbol": chord.Symbol, "voicings": voicings})
+	}
+
 	c.JSON(
-		http.StatusCreated,
+		http.StatusOK,
 		gin.H{
-			"id":          song.ID,
-			"title":       song.Title,
-			"description": song.Description,
-			"content":     song.Content,
-			"uploadedBy":  song.UploadedBy,
-			"artistIds":   artistIds,
+			"instrument": songChords.Instrument,
+			"tuningName": songChords.TuningName,
+			"tuning":     songChords.Tuning,
+			"capo":       songChords.Capo,
+			"chords":     chordList,
 		},
 	)
 }
@@ -92,20 +186,38 @@ func (h *SongHandler) UploadSong(c *gin.Context) {
 	}
 
 	var req struct {
-		Title       string `json:"title" validate:"required"`
-		Description string `json:"description"`
-		Content     string `json:"content" validate:"required"`
-		ArtistIds   []uint `json:"artistIds" validate:"required,min=1"`
+		Title       string   `json:"title" validate:"required"`
+		Description string   `json:"description"`
+		Content     string   `json:"content" validate:"required"`
+		Capo        *uint    `json:"capo"`
+		Instrument  string   `json:"instrument"`
+		Tuning      string   `json:"tuning"`
+		ArtistIds   []uint   `json:"artistIds" validate:"required,min=1"`
+		Tags        []string `json:"tags"`
 	}
 	if !ValidateRequest(c, &req, h.validate) {
 		return
 	}
 
-	song, _, err := h.service.UploadSong(req.Title, req.Description, req.Content, user.ID, req.ArtistIds)
+	song, _, err := h.service.UploadSong(services.SongInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Content:     req.Content,
+		Capo:        req.Capo,
+		Instrument:  req.Instrument,
+		Tuning:      req.Tuning,
+		ArtistIds:   req.ArtistIds,
+		Tags:        req.Tags,
+	}, user.ID)
 	if err != nil {
+		if respondContentErrors(c, err) {
+			return
+		}
 		var statusCode int
 		if err.Error() == "artist not found" {
 			statusCode = http.StatusNotFound
+		} else if isTuningError(err) || errors.Is(err, services.ErrInvalidCapo) {
+			statusCode = http.StatusBadRequest
 		} else {
 			statusCode = http.StatusInternalServerError
 		}
@@ -120,7 +232,40 @@ func (h *SongHandler) UploadSong(c *gin.Context) {
 			"title":       song.Title,
 			"description": song.Description,
 			"content":     song.Content,
+			"capo":        song.Capo,
+			"key":         song.Key,
+			"mode":        song.Mode,
+			"instrument":  song.Instrument,
+			"tuning":      song.Tuning,
+			"tuningNotes": song.TuningNotes,
 			"artistIds":   req.ArtistIds,
+			"tags":        tagNames(song.Tags),
+		},
+	)
+}
+
+func (h *SongHandler) PreviewImport(c *gin.Context) {
+	var req struct {
+		Content string `json:"content" validate:"required"`
+	}
+	if !ValidateRequest(c, &req, h.validate) {
+		return
+	}
+
+	preview, err := h.service.PreviewImport(req.Content)
+	if err != nil {
+		if respondContentErrors(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(
+		http.StatusOK,
+		gin.H{
+			"content":   preview.Content,
+			"converted": preview.Converted,
 		},
 	)
 }
@@ -138,20 +283,38 @@ func (h *SongHandler) UpdateSong(c *gin.Context) {
 	}
 
 	var req struct {
-		Title       string `json:"title" validate:"required"`
-		Description string `json:"description"`
-		Content     string `json:"content" validate:"required"`
-		ArtistIds   []uint `json:"artistIds" validate:"required,min=1"`
+		Title       string   `json:"title" validate:"required"`
+		Description string   `json:"description"`
+		Content     string   `json:"content" validate:"required"`
+		Capo        *uint    `json:"capo"`
+		Instrument  string   `json:"instrument"`
+		Tuning      string   `json:"tuning"`
+		ArtistIds   []uint   `json:"artistIds" validate:"required,min=1"`
+		Tags        []string `json:"tags"`
 	}
 	if !ValidateRequest(c, &req, h.validate) {
 		return
 	}
 
-	song, songArtists, err := h.service.UpdateSong(songId, req.Title, req.Description, req.Content, req.ArtistIds)
+	song, songArtists, err := h.service.UpdateSong(songId, services.SongInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Content:     req.Content,
+		Capo:        req.Capo,
+		Instrument:  req.Instrument,
+		Tuning:      req.Tuning,
+		ArtistIds:   req.ArtistIds,
+		Tags:        req.Tags,
+	})
 	if err != nil {
+		if respondContentErrors(c, err) {
+			return
+		}
 		var statusCode int
 		if err.Error() == "song not found" || err.Error() == "artist not found" {
 			statusCode = http.StatusNotFound
+		} else if isTuningError(err) || errors.Is(err, services.ErrInvalidCapo) {
+			statusCode = http.StatusBadRequest
 		} else {
 			statusCode = http.StatusInternalServerError
 		}
@@ -175,7 +338,100 @@ func (h *SongHandler) UpdateSong(c *gin.Context) {
 			"title":       song.Title,
 			"description": song.Description,
 			"content":     song.Content,
+			"capo":        song.Capo,
+			"key":         song.Key,
+			"mode":        song.Mode,
+			"instrument":  song.Instrument,
+			"tuning":      song.Tuning,
+			"tuningNotes": song.TuningNotes,
 			"artistIds":   artistIds,
+			"tags":        tagNames(song.Tags),
 		},
 	)
 }
+
+// isTuningError reports whether the song's instrument or tuning was
+// rejected.
+func isTuningError(err error) bool {
+	return errors.Is(err, services.ErrInvalidInstrument) || errors.Is(err, services.ErrInvalidTuning)
+}
+
+// parseRenderOptions reads the transpose, capo and simplify query parameters,
+// responding with an error when they are invalid.
+func parseRenderOptions(c *gin.Context) (services.RenderOptions, bool) {
+	transpose, err := parseIntQueryParam(c, "transpose", 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `transpose` parameter. It should be integer number of semitones"})
+		return services.RenderOptions{}, false
+	}
+
+	options := services.RenderOptions{Transpose: transpose}
+	if c.Query("capo") != "" {
+		capo, err := parseUintQueryParam(c, "capo", 0)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `capo` parameter. It should be non negative integer"})
+			return services.RenderOptions{}, false
+		}
+		if err := services.ValidateCapo(&capo); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return services.RenderOptions{}, false
+		}
+		options.Capo = &capo
+	}
+	if c.Query("simplify") != "" {
+		simplify, err := strconv.ParseBool(c.Query("simplify"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid `simplify` parameter. It should be true or false"})
+			return services.RenderOptions{}, false
+		}
+		options.Simplify = simplify
+	}
+	return options, true
+}
+
+// voicingJSON describes a chord diagram. Frets and fingers are listed
+// from the lowest string, -1 is a muted string.
+func voicingJSON(voicing chords.Voicing) gin.H {
+	var barre gin.H
+	if voicing.Barre != nil {
+		barre = gin.H{
+			"fret":       voicing.Barre.Fret,
+			"fromString": voicing.Barre.FromString,
+			"toString":   voicing.Barre.ToString,
+		}
+	}
+	return gin.H{
+		"frets":    voicing.Frets,
+		"fingers":  voicing.Fingers,
+		"baseFret": voicing.BaseFret,
+		"barre":    barre,
+	}
+}
+
+// respondContentErrors responds with the position of every ChordPro error
+// when err is chords.ParseErrors.
+func respondContentErrors(c *gin.Context, err error) bool {
+	var parseErrors chords.ParseErrors
+	if !errors.As(err, &parseErrors) {
+		return false
+	}
+
+	details := make([]gin.H, 0, len(parseErrors))
+	for _, parseError := range parseErrors {
+		details = append(details, gin.H{
+			"line":    parseError.Line,
+			"column":  parseError.Column,
+			"message": parseError.Message,
+		})
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "invalid song content", "details": details})
+	return true
+}
+
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names
+}
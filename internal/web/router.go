@@ -15,6 +15,10 @@ func SetupRouter(
 	userHandler *handlers.UserHandler,
 	artistHandler *handlers.ArtistHandler,
 	songHandler *handlers.SongHandler,
+	searchHandler *handlers.SearchHandler,
+	chordHandler *handlers.ChordHandler,
+	exportHandler *handlers.ExportHandler,
+	importHandler *handlers.ImportHandler,
 	userService services.UserService,
 	rolesConfig *config.Roles,
 ) *gin.Engine {
@@ -28,10 +32,18 @@ func SetupRouter(
 	apiRouter.GET("/artists/:id", artistHandler.GetArtistInformation)
 	apiRouter.GET("/songs/popular", songHandler.GetMostPopularSongs)
 	apiRouter.GET("/songs/:id", songHandler.GetSong)
+	apiRouter.GET("/songs/:id/chords", songHandler.GetSongChords)
+	apiRouter.GET("/songs/:id/export", exportHandler.ExportSong)
+	apiRouter.GET("/chords/*symbol", chordHandler.GetChordDiagram)
+	apiRouter.GET("/search", searchHandler.Search)
+	apiRouter.GET("/search/suggest", searchHandler.Suggest)
+	apiRouter.GET("/search/progression", searchHandler.SearchProgression)
 
 	authRequieredRouter := apiRouter.Group("/", middleware.AuthMiddleware(userService))
 	authRequieredRouter.GET("/users/me", userHandler.GetUserInfo)
+	authRequieredRouter.GET("/users/me/export", exportHandler.ExportMySongs)
 	authRequieredRouter.POST("/songs", songHandler.UploadSong)
+	authRequieredRouter.POST("/songs/import/preview", songHandler.PreviewImport)
 	authRequieredRouter.PUT("songs/:id", songHandler.UpdateSong)
 
 	adminOnlyRouter := authRequieredRouter.Group("/", middleware.VerifyRoleMiddleware(userService, rolesConfig.Admin))
@@ -39,6 +51,9 @@ func SetupRouter(
 	adminOnlyRouter.PUT("/artists/:id", artistHandler.UpdateArtist)
 	adminOnlyRouter.DELETE("/artists/:id", artistHandler.DeleteArtist)
 	adminOnlyRouter.POST("/users/create", userHandler.CreateNewUser)
+	adminOnlyRouter.GET("/export", exportHandler.ExportAllSongs)
+	adminOnlyRouter.POST("/imports", importHandler.CreateImportJob)
+	adminOnlyRouter.GET("/imports/:id", importHandler.GetImportJob)
 
 	return r
 }